@@ -0,0 +1,186 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// Аудит: каждый не-GET запрос через HttpClient.req дописывается строкой
+// JSON в файл (append-only). Путь — audit_log контекста или audit.jsonl
+// рядом с конфигом.
+
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	SudoUser string    `json:"sudo_user,omitempty"`
+	Context  string    `json:"context"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Status   int       `json:"status"`          // 0 — ответа не было
+	Error    string    `json:"error,omitempty"` // сетевая ошибка
+	Command  string    `json:"command"`
+}
+
+type AuditLog struct {
+	path    string
+	context string
+
+	mu     sync.Mutex
+	warned bool
+}
+
+// AuditPath — файл аудита контекста.
+func AuditPath(cx *pulsarContext.Context) (string, error) {
+	if cx.AuditLog != "" {
+		return cx.AuditLog, nil
+	}
+	dir, err := pulsarConfig.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.jsonl"), nil
+}
+
+func NewAuditLog(path, context string) *AuditLog {
+	return &AuditLog{path: path, context: context}
+}
+
+// record пишет одну запись. Ошибка записи не должна ронять команду:
+// предупреждаем в stderr один раз. nil-safe.
+func (a *AuditLog) record(method, path string, status int, reqErr error) {
+	if a == nil {
+		return
+	}
+	e := AuditEntry{
+		Time:     time.Now().UTC(),
+		User:     osUser(),
+		SudoUser: os.Getenv("SUDO_USER"),
+		Context:  a.context,
+		Method:   method,
+		Path:     path,
+		Status:   status,
+		Command:  CommandLine(),
+	}
+	if reqErr != nil {
+		e.Error = reqErr.Error()
+	}
+	b, _ := json.Marshal(e)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := appendLine(a.path, b); err != nil && !a.warned {
+		a.warned = true
+		fmt.Fprintf(os.Stderr, "warn: audit log %s: %v\n", a.path, err)
+	}
+}
+
+// ReadAuditLog читает записи не старше since (нулевое since — все).
+// Битые строки пропускаются: файл могли дописывать параллельно.
+func ReadAuditLog(path string, since time.Time) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var out []AuditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Time.Before(since) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// CommandLine — os.Args одной строкой, аргументы с пробелами в кавычках.
+// Значения секретных флагов (--token и т.п.) скрыты, как в `context get`:
+// аудит хранят и пересылают.
+func CommandLine() string {
+	return commandLine(os.Args)
+}
+
+func commandLine(args []string) string {
+	args = redactArgs(args)
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\n'\"\\$") {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		parts[i] = a
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactArgs скрывает значения SecretFlags в формах --flag v, --flag=v,
+// -flag v и -flag=v; ссылки env:/file:/exec: остаются видны.
+func redactArgs(args []string) []string {
+	out := slices.Clone(args)
+	for i := 0; i < len(out); i++ {
+		a := out[i]
+		if a == "--" {
+			break
+		}
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+		name, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !slices.Contains(pulsarConfig.SecretFlags, name) {
+			continue
+		}
+		if hasVal {
+			out[i] = a[:len(a)-len(val)] + pulsarConfig.RedactSecret(val)
+		} else if i+1 < len(out) {
+			i++
+			out[i] = pulsarConfig.RedactSecret(out[i])
+		}
+	}
+	return out
+}
+
+// helpers
+
+func osUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if v := os.Getenv("USER"); v != "" {
+		return v
+	}
+	return os.Getenv("USERNAME")
+}
+
+func appendLine(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	// одна запись на строку — с O_APPEND строки не перемешиваются
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,26 @@
+package client
+
+import "testing"
+
+func TestCommandLineRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"puls", "list", "--full"}, "puls list --full"},
+		{[]string{"puls", "context", "set", "--name", "x", "--token", "s3cr3t"}, "puls context set --name x --token <redacted>"},
+		{[]string{"puls", "context", "set", "--token=s3cr3t"}, "puls context set --token=<redacted>"},
+		{[]string{"puls", "context", "set", "-basic-password", "p w"}, "puls context set -basic-password <redacted>"},
+		{[]string{"puls", "context", "set", "-oauth2-client-secret=x"}, "puls context set -oauth2-client-secret=<redacted>"},
+		{[]string{"puls", "context", "set", "--token", "env:PULSAR_TOKEN"}, "puls context set --token env:PULSAR_TOKEN"},
+		{[]string{"puls", "context", "set", "--token-file", "/tmp/tok"}, "puls context set --token-file /tmp/tok"},
+		{[]string{"puls", "context", "set", "--token"}, "puls context set --token"},
+		{[]string{"puls", "x", "--", "--token", "v"}, "puls x -- --token v"},
+		{[]string{"puls", "subscriptions", "delete", "--sub", "my sub"}, "puls subscriptions delete --sub 'my sub'"},
+	}
+	for _, tt := range tests {
+		if got := commandLine(tt.args); got != tt.want {
+			t.Errorf("commandLine(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// типы auth_type в контексте
+const (
+	AuthNone      = "none"
+	AuthToken     = "token"
+	AuthTokenFile = "token_file"
+	AuthTokenExec = "token_exec"
+	AuthOAuth2    = "oauth2"
+	AuthBasic     = "basic"
+)
+
+var AuthTypes = []string{AuthNone, AuthToken, AuthTokenFile, AuthTokenExec, AuthOAuth2, AuthBasic}
+
+const defaultTokenExecTTL = 5 * time.Minute
+
+// AuthProvider добавляет к запросу заголовок авторизации.
+type AuthProvider interface {
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// invalidator — провайдеры с кэшем; после 401 кэш сбрасывается
+// и запрос повторяется один раз со свежим токеном.
+type invalidator interface {
+	Invalidate()
+}
+
+// NewAuthProvider выбирает провайдер по auth_type, а если он не задан —
+// по первому заполненному полю контекста. nil означает «без авторизации».
+func NewAuthProvider(cx *pulsarContext.Context, hc *http.Client) (AuthProvider, error) {
+	typ := cx.AuthType
+	if typ == "" {
+		switch {
+		case cx.Token != "":
+			typ = AuthToken
+		case cx.TokenFile != "":
+			typ = AuthTokenFile
+		case cx.TokenExec != "":
+			typ = AuthTokenExec
+		case cx.OAuth2IssuerURL != "" || cx.OAuth2CredentialsFile != "":
+			typ = AuthOAuth2
+		case cx.BasicUser != "":
+			typ = AuthBasic
+		default:
+			typ = AuthNone
+		}
+	}
+
+	switch typ {
+	case AuthNone:
+		return nil, nil
+	case AuthToken:
+		if cx.Token == "" {
+			return nil, errors.New("auth_type token requires token")
+		}
+		return staticToken(cx.Token), nil
+	case AuthTokenFile:
+		if cx.TokenFile == "" {
+			return nil, errors.New("auth_type token_file requires token_file")
+		}
+		return tokenFile(cx.TokenFile), nil
+	case AuthTokenExec:
+		if cx.TokenExec == "" {
+			return nil, errors.New("auth_type token_exec requires token_exec")
+		}
+		ttl := defaultTokenExecTTL
+		if cx.TokenExecTTLSec > 0 {
+			ttl = time.Duration(cx.TokenExecTTLSec) * time.Second
+		}
+		return &tokenExec{command: cx.TokenExec, ttl: ttl}, nil
+	case AuthOAuth2:
+		return newOAuth2(cx, hc)
+	case AuthBasic:
+		if cx.BasicUser == "" {
+			return nil, errors.New("auth_type basic requires basic_user")
+		}
+		return basicAuth{user: cx.BasicUser, pass: cx.BasicPassword}, nil
+	default:
+		return nil, fmt.Errorf("unknown auth_type %q (supported: %s)", typ, strings.Join(AuthTypes, ", "))
+	}
+}
+
+// static token
+
+type staticToken string
+
+func (t staticToken) Apply(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// token file: читается на каждый запрос, чтобы подхватывать ротацию
+
+type tokenFile string
+
+func (p tokenFile) Apply(_ context.Context, req *http.Request) error {
+	b, err := os.ReadFile(string(p))
+	if err != nil {
+		return fmt.Errorf("read token_file: %w", err)
+	}
+	tok := strings.TrimSpace(string(b))
+	if tok == "" {
+		return fmt.Errorf("token_file %s is empty", string(p))
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return nil
+}
+
+// token exec: stdout команды, кэшируется на ttl
+
+type tokenExec struct {
+	command string
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (p *tokenExec) Apply(ctx context.Context, req *http.Request) error {
+	tok, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return nil
+}
+
+func (p *tokenExec) get(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+	out, err := pulsarConfig.RunShell(ctx, p.command)
+	if err != nil {
+		return "", fmt.Errorf("token_exec: %w", err)
+	}
+	tok := strings.TrimSpace(out)
+	if tok == "" {
+		return "", errors.New("token_exec: command printed an empty token")
+	}
+	p.token = tok
+	p.expires = time.Now().Add(p.ttl)
+	return tok, nil
+}
+
+func (p *tokenExec) Invalidate() {
+	p.mu.Lock()
+	p.token = ""
+	p.mu.Unlock()
+}
+
+// oauth2 client credentials
+
+type oauth2ClientCredentials struct {
+	hc           *http.Client
+	issuerURL    string
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	audience     string
+	scope        string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// oauth2CredentialsFile — формат key-файла, который использует сам Pulsar.
+type oauth2CredentialsFile struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	IssuerURL    string `json:"issuer_url"`
+}
+
+func newOAuth2(cx *pulsarContext.Context, hc *http.Client) (*oauth2ClientCredentials, error) {
+	p := &oauth2ClientCredentials{
+		hc:           hc,
+		issuerURL:    cx.OAuth2IssuerURL,
+		clientID:     cx.OAuth2ClientID,
+		clientSecret: cx.OAuth2ClientSecret,
+		audience:     cx.OAuth2Audience,
+		scope:        cx.OAuth2Scope,
+	}
+	if cx.OAuth2CredentialsFile != "" {
+		b, err := os.ReadFile(cx.OAuth2CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read oauth2_credentials_file: %w", err)
+		}
+		var f oauth2CredentialsFile
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("parse oauth2_credentials_file: %w", err)
+		}
+		if p.clientID == "" {
+			p.clientID = f.ClientID
+		}
+		if p.clientSecret == "" {
+			p.clientSecret = f.ClientSecret
+		}
+		if p.issuerURL == "" {
+			p.issuerURL = f.IssuerURL
+		}
+	}
+	if p.issuerURL == "" || p.clientID == "" || p.clientSecret == "" {
+		return nil, errors.New("auth_type oauth2 requires oauth2_issuer_url, oauth2_client_id and oauth2_client_secret (or oauth2_credentials_file)")
+	}
+	return p, nil
+}
+
+func (p *oauth2ClientCredentials) Apply(ctx context.Context, req *http.Request) error {
+	tok, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return nil
+}
+
+func (p *oauth2ClientCredentials) Invalidate() {
+	p.mu.Lock()
+	p.token = ""
+	p.mu.Unlock()
+}
+
+func (p *oauth2ClientCredentials) get(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+	if p.tokenURL == "" {
+		u, err := p.discoverTokenURL(ctx)
+		if err != nil {
+			return "", err
+		}
+		p.tokenURL = u
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	if p.audience != "" {
+		form.Set("audience", p.audience)
+	}
+	if p.scope != "" {
+		form.Set("scope", p.scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("oauth2 token: %s (%s)", resp.Status, string(b))
+	}
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(b, &tr); err != nil {
+		return "", fmt.Errorf("oauth2 token: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return "", errors.New("oauth2 token: empty access_token in response")
+	}
+	ttl := time.Duration(tr.ExpiresIn) * time.Second
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	// обновляем заранее, чтобы токен не истёк посреди запроса
+	if ttl > time.Minute {
+		ttl -= 30 * time.Second
+	}
+	p.token = tr.AccessToken
+	p.expires = time.Now().Add(ttl)
+	return p.token, nil
+}
+
+func (p *oauth2ClientCredentials) discoverTokenURL(ctx context.Context) (string, error) {
+	u := strings.TrimRight(p.issuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("oauth2 discovery %s: %s (%s)", u, resp.Status, string(b))
+	}
+	var md struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.Unmarshal(b, &md); err != nil {
+		return "", fmt.Errorf("oauth2 discovery: %w", err)
+	}
+	if md.TokenEndpoint == "" {
+		return "", fmt.Errorf("oauth2 discovery %s: no token_endpoint", u)
+	}
+	return md.TokenEndpoint, nil
+}
+
+// basic auth
+
+type basicAuth struct {
+	user string
+	pass string
+}
+
+func (p basicAuth) Apply(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(p.user, p.pass)
+	return nil
+}
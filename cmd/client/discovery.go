@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+)
+
+// ListTenants — GET /tenants
+func ListTenants(ctx context.Context, h *HttpClient) ([]string, error) {
+	return getStringList(ctx, h, "/tenants", "list tenants")
+}
+
+// ListNamespaces — GET /namespaces/{tenant}; имена в виде "tenant/ns".
+func ListNamespaces(ctx context.Context, h *HttpClient, tenant string) ([]string, error) {
+	return getStringList(ctx, h, "/namespaces/"+url.PathEscape(tenant), "list namespaces of "+tenant)
+}
+
+// ListClusters — GET /clusters
+func ListClusters(ctx context.Context, h *HttpClient) ([]string, error) {
+	return getStringList(ctx, h, "/clusters", "list clusters")
+}
+
+// helpers
+
+func getStringList(ctx context.Context, h *HttpClient, path, what string) ([]string, error) {
+	resp, err := h.req(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s (%s)", what, resp.Status, string(b))
+	}
+	var arr []string
+	if err := json.NewDecoder(resp.Body).Decode(&arr); err != nil {
+		return nil, err
+	}
+	sort.Strings(arr)
+	return arr, nil
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// виды топиков для фильтра по kind
+const (
+	KindNonPartitioned = "non-partitioned"
+	KindPartitioned    = "partitioned"
+)
+
+// виды шаблонов
+const (
+	PatternPrefix = "prefix"
+	PatternGlob   = "glob"
+	PatternRegex  = "regex"
+)
+
+// Pattern — один шаблон имени топика. Записывается как "prefix:orders-",
+// "glob:*-dlq", "regex:-(dlq|retry)$"; без вида — glob, если есть *?[,
+// иначе prefix. Шаблон, содержащий "://", сравнивается с полным именем.
+type Pattern struct {
+	Kind  string
+	Value string
+	Full  bool // сравнивать с FullName, а не с коротким Name
+
+	re *regexp.Regexp
+}
+
+func ParsePattern(s string, full bool) (Pattern, error) {
+	p := Pattern{Full: full}
+	kind, value, ok := strings.Cut(s, ":")
+	switch {
+	case ok && (kind == PatternPrefix || kind == PatternGlob || kind == PatternRegex):
+		p.Kind, p.Value = kind, value
+	case strings.ContainsAny(s, "*?["):
+		p.Kind, p.Value = PatternGlob, s
+	default:
+		p.Kind, p.Value = PatternPrefix, s
+	}
+	if p.Value == "" {
+		return p, fmt.Errorf("empty pattern %q", s)
+	}
+	if strings.Contains(p.Value, "://") {
+		p.Full = true
+	}
+
+	switch p.Kind {
+	case PatternGlob:
+		if _, err := path.Match(p.Value, ""); err != nil {
+			return p, fmt.Errorf("glob %q: %w", p.Value, err)
+		}
+	case PatternRegex:
+		re, err := regexp.Compile(p.Value)
+		if err != nil {
+			return p, fmt.Errorf("regex %q: %w", p.Value, err)
+		}
+		p.re = re
+	}
+	return p, nil
+}
+
+func (p Pattern) Match(t TopicRef) bool {
+	name := t.Name
+	if p.Full {
+		name = t.FullName
+	}
+	switch p.Kind {
+	case PatternGlob:
+		ok, _ := path.Match(p.Value, name)
+		return ok
+	case PatternRegex:
+		return p.re.MatchString(name)
+	default:
+		return strings.HasPrefix(name, p.Value)
+	}
+}
+
+func (p Pattern) String() string {
+	return p.Kind + ":" + p.Value
+}
+
+// TopicFilter — отбор топиков по имени, виду и бэклогу.
+// Топик проходит, если начинается с Prefix, совпадает хотя бы с одним
+// Include (или Include пуст) и не совпадает ни с одним Exclude.
+type TopicFilter struct {
+	Prefix  string
+	Include []Pattern
+	Exclude []Pattern
+	Kinds   []string // пусто = все
+
+	MinBacklog *int64
+	MaxBacklog *int64
+}
+
+// NewTopicFilter собирает фильтр из строковых шаблонов (см. ParsePattern);
+// full — сравнивать шаблоны без "://" тоже с полным именем.
+func NewTopicFilter(prefix string, include, exclude []string, full bool) (*TopicFilter, error) {
+	f := &TopicFilter{Prefix: prefix}
+	for _, s := range include {
+		p, err := ParsePattern(s, full)
+		if err != nil {
+			return nil, fmt.Errorf("include: %w", err)
+		}
+		f.Include = append(f.Include, p)
+	}
+	for _, s := range exclude {
+		p, err := ParsePattern(s, full)
+		if err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+		f.Exclude = append(f.Exclude, p)
+	}
+	return f, nil
+}
+
+// MatchName — проверки, не требующие stats.
+func (f *TopicFilter) MatchName(t TopicRef) bool {
+	if f == nil {
+		return true
+	}
+	if f.Prefix != "" && !strings.HasPrefix(t.Name, f.Prefix) {
+		return false
+	}
+	if len(f.Include) > 0 {
+		ok := false
+		for _, p := range f.Include {
+			if p.Match(t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, p := range f.Exclude {
+		if p.Match(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// WantKind — нужно ли вообще смотреть топики этого вида.
+func (f *TopicFilter) WantKind(kind string) bool {
+	if f == nil || len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *TopicFilter) MatchBacklog(backlog int64) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinBacklog != nil && backlog < *f.MinBacklog {
+		return false
+	}
+	if f.MaxBacklog != nil && backlog > *f.MaxBacklog {
+		return false
+	}
+	return true
+}
+
+// FilterTopics оставляет топики, прошедшие MatchName.
+func (f *TopicFilter) FilterTopics(topics []TopicRef) []TopicRef {
+	if f == nil {
+		return topics
+	}
+	out := make([]TopicRef, 0, len(topics))
+	for _, t := range topics {
+		if f.MatchName(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// FilterBacklogs оставляет результаты с бэклогом в диапазоне;
+// ошибки пропускаются как есть, чтобы вызывающий их показал.
+func (f *TopicFilter) FilterBacklogs(infos []TopicBacklog) []TopicBacklog {
+	if f == nil || (f.MinBacklog == nil && f.MaxBacklog == nil) {
+		return infos
+	}
+	out := infos[:0:0]
+	for _, info := range infos {
+		if info.Err != nil || f.MatchBacklog(info.Backlog) {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+// String — краткое описание для verbose-логов.
+func (f *TopicFilter) String() string {
+	if f == nil {
+		return "none"
+	}
+	var parts []string
+	if f.Prefix != "" {
+		parts = append(parts, fmt.Sprintf("prefix=%q", f.Prefix))
+	}
+	for _, p := range f.Include {
+		parts = append(parts, "include="+p.String())
+	}
+	for _, p := range f.Exclude {
+		parts = append(parts, "exclude="+p.String())
+	}
+	if len(f.Kinds) > 0 {
+		parts = append(parts, "kind="+strings.Join(f.Kinds, ","))
+	}
+	if f.MinBacklog != nil {
+		parts = append(parts, fmt.Sprintf("min-backlog=%d", *f.MinBacklog))
+	}
+	if f.MaxBacklog != nil {
+		parts = append(parts, fmt.Sprintf("max-backlog=%d", *f.MaxBacklog))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		in       string
+		full     bool
+		kind     string
+		value    string
+		wantFull bool
+		wantErr  bool
+	}{
+		{in: "orders-", kind: PatternPrefix, value: "orders-"},
+		{in: "*-dlq", kind: PatternGlob, value: "*-dlq"},
+		{in: "order?", kind: PatternGlob, value: "order?"},
+		{in: "orders-[ab]", kind: PatternGlob, value: "orders-[ab]"},
+		{in: "prefix:a*", kind: PatternPrefix, value: "a*"},
+		{in: "glob:orders", kind: PatternGlob, value: "orders"},
+		{in: "regex:-(dlq|retry)$", kind: PatternRegex, value: "-(dlq|retry)$"},
+		{in: "orders", full: true, kind: PatternPrefix, value: "orders", wantFull: true},
+		{in: "persistent://t/ns/orders", kind: PatternPrefix, value: "persistent://t/ns/orders", wantFull: true},
+		{in: "persistent://t/ns/*-dlq", kind: PatternGlob, value: "persistent://t/ns/*-dlq", wantFull: true},
+		{in: "glob:non-persistent://t/ns/*", kind: PatternGlob, value: "non-persistent://t/ns/*", wantFull: true},
+		// неизвестный вид — часть значения
+		{in: "foo:bar", kind: PatternPrefix, value: "foo:bar"},
+
+		{in: "", wantErr: true},
+		{in: "prefix:", wantErr: true},
+		{in: "glob:[", wantErr: true},
+		{in: "regex:(", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := ParsePattern(tt.in, tt.full)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePattern(%q): expected error, got %+v", tt.in, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", tt.in, err)
+			continue
+		}
+		if p.Kind != tt.kind || p.Value != tt.value || p.Full != tt.wantFull {
+			t.Errorf("ParsePattern(%q) = %s full=%v, want %s:%s full=%v", tt.in, p, p.Full, tt.kind, tt.value, tt.wantFull)
+		}
+	}
+}
+
+func TestTopicFilterMatchName(t *testing.T) {
+	ref := func(name string) TopicRef {
+		return TopicRef{FullName: "persistent://t/ns/" + name, Tenant: "t", Namespace: "ns", Name: name}
+	}
+	tests := []struct {
+		name    string
+		prefix  string
+		include []string
+		exclude []string
+		full    bool
+		topic   string
+		want    bool
+	}{
+		{name: "no filter", topic: "orders", want: true},
+		{name: "prefix", prefix: "ord", topic: "orders", want: true},
+		{name: "prefix miss", prefix: "bill", topic: "orders", want: false},
+		{name: "include any", include: []string{"bill", "*-dlq"}, topic: "orders-dlq", want: true},
+		{name: "include none", include: []string{"bill", "regex:^x"}, topic: "orders", want: false},
+		{name: "exclude", exclude: []string{"*-dlq"}, topic: "orders-dlq", want: false},
+		{name: "exclude beats include", include: []string{"orders"}, exclude: []string{"*-dlq"}, topic: "orders-dlq", want: false},
+		{name: "include then exclude miss", include: []string{"orders"}, exclude: []string{"*-dlq"}, topic: "orders-1", want: true},
+		{name: "prefix checked before include", prefix: "bill", include: []string{"orders"}, topic: "orders", want: false},
+		{name: "short name by default", include: []string{"glob:t/*"}, topic: "orders", want: false},
+		{name: "match-full-name", include: []string{"regex:^persistent://t/ns/ord"}, full: true, topic: "orders", want: true},
+		{name: "full name pattern", exclude: []string{"persistent://t/ns/ord"}, topic: "orders", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTopicFilter(tt.prefix, tt.include, tt.exclude, tt.full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.MatchName(ref(tt.topic)); got != tt.want {
+				t.Errorf("MatchName(%s) = %v, want %v (filter %s)", tt.topic, got, tt.want, f)
+			}
+		})
+	}
+}
+
+func TestTopicFilterFilterBacklogs(t *testing.T) {
+	one, ten := int64(1), int64(10)
+	errStats := errors.New("stats: 500")
+	infos := []TopicBacklog{
+		{Ref: TopicRef{Name: "zero"}, Backlog: 0},
+		{Ref: TopicRef{Name: "five"}, Backlog: 5},
+		{Ref: TopicRef{Name: "broken"}, Err: errStats},
+		{Ref: TopicRef{Name: "big"}, Backlog: 100},
+	}
+	tests := []struct {
+		name     string
+		min, max *int64
+		want     []string
+	}{
+		{name: "no bounds", want: []string{"zero", "five", "broken", "big"}},
+		{name: "min", min: &one, want: []string{"five", "broken", "big"}},
+		{name: "max", max: &ten, want: []string{"zero", "five", "broken"}},
+		{name: "range", min: &one, max: &ten, want: []string{"five", "broken"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &TopicFilter{MinBacklog: tt.min, MaxBacklog: tt.max}
+			got := f.FilterBacklogs(infos)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %v", len(got), tt.want)
+			}
+			for i, info := range got {
+				if info.Ref.Name != tt.want[i] {
+					t.Errorf("result %d = %s, want %s", i, info.Ref.Name, tt.want[i])
+				}
+				if info.Ref.Name == "broken" && !errors.Is(info.Err, errStats) {
+					t.Errorf("error not passed through: %v", info.Err)
+				}
+			}
+		})
+	}
+}
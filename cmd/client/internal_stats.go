@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Модель /internalStats (managed ledger) — только поля, которые нужны puls.
+
+type LedgerInfo struct {
+	LedgerID  int64 `json:"ledgerId"`
+	Entries   int64 `json:"entries"`
+	Size      int64 `json:"size"`
+	Offloaded bool  `json:"offloaded"`
+}
+
+type CursorInternalStats struct {
+	MarkDeletePosition      string `json:"markDeletePosition"`
+	ReadPosition            string `json:"readPosition"`
+	MessagesConsumedCounter int64  `json:"messagesConsumedCounter"`
+	LastLedgerSwitchTime    string `json:"lastLedgerSwitchTimestamp"`
+	State                   string `json:"state"`
+	Active                  bool   `json:"active"`
+}
+
+type InternalStats struct {
+	EntriesAddedCounter        int64                          `json:"entriesAddedCounter"`
+	NumberOfEntries            int64                          `json:"numberOfEntries"`
+	TotalSize                  int64                          `json:"totalSize"`
+	CurrentLedgerEntries       int64                          `json:"currentLedgerEntries"`
+	CurrentLedgerSize          int64                          `json:"currentLedgerSize"`
+	LastLedgerCreatedTimestamp string                         `json:"lastLedgerCreatedTimestamp"`
+	LastConfirmedEntry         string                         `json:"lastConfirmedEntry"`
+	State                      string                         `json:"state"`
+	Ledgers                    []LedgerInfo                   `json:"ledgers"`
+	Cursors                    map[string]CursorInternalStats `json:"cursors"`
+}
+
+type PartitionedInternalStats struct {
+	Metadata   PartitionedTopicMetadata `json:"metadata"`
+	Partitions map[string]InternalStats `json:"partitions"`
+}
+
+func GetInternalStats(ctx context.Context, h *HttpClient, t TopicRef) (*InternalStats, error) {
+	resp, err := h.req(ctx, "GET", topicPath(t)+"/internalStats", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("internal stats %s: %s (%s)", t.FullName, resp.Status, string(b))
+	}
+	var st InternalStats
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func GetPartitionedInternalStats(ctx context.Context, h *HttpClient, t TopicRef) (*PartitionedInternalStats, error) {
+	resp, err := h.req(ctx, "GET", topicPath(t)+"/partitioned-internalStats", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("partitioned internal stats %s: %s (%s)", t.FullName, resp.Status, string(b))
+	}
+	var st PartitionedInternalStats
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// IdleSince — момент, с которого в топик точно ничего не публиковали.
+// Текущий ledger создаётся при ролловере или загрузке топика; если в нём
+// нет записей, последняя публикация была не позже его создания.
+// ok=false, если это нельзя утверждать (в текущем ledger есть записи).
+func (s *InternalStats) IdleSince() (time.Time, bool) {
+	if s.CurrentLedgerEntries > 0 {
+		return time.Time{}, false
+	}
+	ts, err := parsePulsarTime(s.LastLedgerCreatedTimestamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// IdleSince для partitioned — по самой «свежей» партиции.
+func (s *PartitionedInternalStats) IdleSince() (time.Time, bool) {
+	var latest time.Time
+	for _, p := range s.Partitions {
+		ts, ok := p.IdleSince()
+		if !ok {
+			return time.Time{}, false
+		}
+		if ts.After(latest) {
+			latest = ts
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// parsePulsarTime — брокер пишет время как ISO-8601 с миллисекундами и зоной.
+func parsePulsarTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999Z0700", "2006-01-02T15:04:05.999-07:00"} {
+		if ts, err := time.Parse(layout, s); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
 	"encoding/json"
 	"net/url"
@@ -15,43 +16,168 @@ import (
 )
 
 type HttpClient struct {
-	base string
-	tok  string
-	c    *http.Client
+	base    string
+	auth    AuthProvider
+	c       *http.Client
+	retry   RetryPolicy
+	limiter *RateLimiter
+	audit   *AuditLog // не-GET запросы
 }
 
 type TopicRef struct {
 	FullName  string
+	Domain    string // DomainPersistent | DomainNonPersistent, пусто = persistent
 	Tenant    string
 	Namespace string
 	Name      string
 }
 
+// домены топиков (схема в полном имени и первый сегмент пути admin API)
+const (
+	DomainPersistent    = "persistent"
+	DomainNonPersistent = "non-persistent"
+)
+
+// IsPersistent — у топика есть хранилище (ledgers, internal stats, бэклог).
+func (t TopicRef) IsPersistent() bool {
+	return t.Domain == "" || t.Domain == DomainPersistent
+}
+
+func (t TopicRef) domain() string {
+	if t.Domain == "" {
+		return DomainPersistent
+	}
+	return t.Domain
+}
+
 type TopicBacklog struct {
     Ref     TopicRef
     Backlog int64
     Empty   bool
+    Stats   *TopicStats // для partitioned — агрегированные по партициям
     Err     error
 }
 
-func NewHTTP(ctx *pulsarContext.Context) *HttpClient {
-	return &HttpClient{
-		base: strings.TrimRight(ctx.AdminURL, "/"),
-		tok:  ctx.Token,
-		c:    &http.Client{Timeout: time.Duration(ctx.HTTPTimeoutSec) * time.Second},
+func NewHTTP(ctx *pulsarContext.Context) (*HttpClient, error) {
+	c := &http.Client{Timeout: time.Duration(ctx.HTTPTimeoutSec) * time.Second}
+	tr, err := buildTransport(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("context %q: %w", ctx.Name, err)
+	}
+	if tr != nil {
+		c.Transport = tr
+	}
+	auth, err := NewAuthProvider(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("context %q: %w", ctx.Name, err)
 	}
+	auditPath, err := AuditPath(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("context %q: audit log: %w", ctx.Name, err)
+	}
+	return &HttpClient{
+		base:  strings.TrimRight(ctx.AdminURL, "/"),
+		auth:  auth,
+		c:     c,
+		retry: RetryPolicyFromContext(ctx),
+		audit: NewAuditLog(auditPath, ctx.Name),
+	}, nil
+}
+
+// SetRateLimit ограничивает число запросов в секунду (0 — без ограничения).
+func (h *HttpClient) SetRateLimit(rps float64) {
+	h.limiter = NewRateLimiter(rps)
 }
 
-func (h *HttpClient) req(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+// req выполняет запрос с повторами по h.retry: сетевые ошибки, 429 и 5xx
+// шлюза ретраятся с backoff (или по Retry-After), POST — только если разрешено.
+func (h *HttpClient) req(ctx context.Context, method, path string, body io.Reader) (resp *http.Response, err error) {
 	if !strings.HasPrefix(path, "/") {
 		path = "/" + path
 	}
+	if method != http.MethodGet {
+		defer func() {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			h.audit.record(method, path, status, err)
+		}()
+	}
+	// тело читаем один раз, чтобы можно было отправить его повторно
+	var payload []byte
+	if body != nil {
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	}
+
+	attempts := h.retry.MaxAttempts
+	if attempts < 1 || !h.retry.allows(method) {
+		attempts = 1
+	}
+
+	reauthed := false
+	for attempt := 0; ; attempt++ {
+		// лимит считает каждую попытку, включая повторы
+		if err := h.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := h.do(ctx, method, path, payload)
+		last := attempt+1 >= attempts
+
+		// протухший токен из кэша: сбрасываем и повторяем один раз
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !reauthed {
+			if inv, ok := h.auth.(invalidator); ok {
+				reauthed = true
+				inv.Invalidate()
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				attempt--
+				continue
+			}
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if last || !retryableError(ctx, err) {
+				return nil, err
+			}
+			wait = h.retry.backoff(attempt)
+		case retryableStatus(resp.StatusCode) && !last:
+			d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				d = h.retry.backoff(attempt)
+			}
+			wait = d
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (h *HttpClient) do(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 	req, err := http.NewRequestWithContext(ctx, method, h.base+path, body)
 	if err != nil {
 		return nil, err
 	}
-	if h.tok != "" {
-		req.Header.Set("Authorization", "Bearer "+h.tok)
+	if h.auth != nil {
+		if err := h.auth.Apply(ctx, req); err != nil {
+			return nil, err
+		}
 	}
 	if method == "POST" || method == "PUT" || method == "DELETE" {
 		req.Header.Set("Content-Type", "application/json")
@@ -65,33 +191,7 @@ func ListNonPartitionedTopics(
 	tenant, ns string,
 	includeSystem bool,
 ) ([]TopicRef, error) {
-	q := ""
-	if includeSystem {
-		q = "?includeSystem=true"
-	}
-	path := fmt.Sprintf("/persistent/%s/%s%s", url.PathEscape(tenant), url.PathEscape(ns), q)
-	resp, err := h.req(ctx, "GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list non-partitioned topics: %s (%s)", resp.Status, string(b))
-	}
-	var arr []string
-	if err := json.NewDecoder(resp.Body).Decode(&arr); err != nil {
-		return nil, err
-	}
-	res := make([]TopicRef, 0, len(arr))
-	for _, s := range arr {
-		tr, err := parseFullTopicName(s)
-		if err != nil {
-			continue
-		}
-		res = append(res, tr)
-	}
-	return res, nil
+	return ListTopics(ctx, h, DomainPersistent, tenant, ns, false, includeSystem)
 }
 
 func ListPartitionedTopics(
@@ -100,11 +200,29 @@ func ListPartitionedTopics(
 	tenant, ns string,
 	includeSystem bool,
 ) ([]TopicRef, error) {
-	q := ""
+	return ListTopics(ctx, h, DomainPersistent, tenant, ns, true, includeSystem)
+}
+
+// ListTopics — топики namespace в домене persistent или non-persistent;
+// partitioned=true — только partitioned-топики.
+func ListTopics(
+	ctx context.Context,
+	h *HttpClient,
+	domain, tenant, ns string,
+	partitioned, includeSystem bool,
+) ([]TopicRef, error) {
+	what := "non-partitioned"
+	path := fmt.Sprintf("/%s/%s/%s", domain, url.PathEscape(tenant), url.PathEscape(ns))
+	if partitioned {
+		what = "partitioned"
+		path += "/partitioned"
+	}
 	if includeSystem {
-		q = "?includeSystem=true"
+		path += "?includeSystem=true"
+	}
+	if domain != DomainPersistent {
+		what = domain + " " + what
 	}
-	path := fmt.Sprintf("/persistent/%s/%s/partitioned%s", url.PathEscape(tenant), url.PathEscape(ns), q)
 	resp, err := h.req(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -112,7 +230,7 @@ func ListPartitionedTopics(
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list partitioned topics: %s (%s)", resp.Status, string(b))
+		return nil, fmt.Errorf("list %s topics: %s (%s)", what, resp.Status, string(b))
 	}
 	var arr []string
 	if err := json.NewDecoder(resp.Body).Decode(&arr); err != nil {
@@ -129,13 +247,8 @@ func ListPartitionedTopics(
 	return res, nil
 }
 
-
-func getNonPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (map[string]any, error) {
-	path := fmt.Sprintf("/persistent/%s/%s/%s/stats",
-		url.PathEscape(t.Tenant),
-		url.PathEscape(t.Namespace),
-		url.PathEscape(t.Name),
-	)
+func GetNonPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (*TopicStats, error) {
+	path := topicPath(t) + "/stats"
 	resp, err := h.req(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -145,19 +258,15 @@ func getNonPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (map
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("stats %s: %s (%s)", t.FullName, resp.Status, string(b))
 	}
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
+	var st TopicStats
+	if err := json.Unmarshal(b, &st); err != nil {
 		return nil, err
 	}
-	return m, nil
+	return &st, nil
 }
 
-func GetPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (map[string]any, error) {
-	path := fmt.Sprintf("/persistent/%s/%s/%s/partitioned-stats",
-		url.PathEscape(t.Tenant),
-		url.PathEscape(t.Namespace),
-		url.PathEscape(t.Name),
-	)
+func GetPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (*PartitionedTopicStats, error) {
+	path := topicPath(t) + "/partitioned-stats"
 	resp, err := h.req(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -167,20 +276,20 @@ func GetPartitionedStats(ctx context.Context, h *HttpClient, t TopicRef) (map[st
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("partitioned-stats %s: %s (%s)", t.FullName, resp.Status, string(b))
 	}
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
+	var st PartitionedTopicStats
+	if err := json.Unmarshal(b, &st); err != nil {
 		return nil, err
 	}
-	return m, nil
+	return &st, nil
 }
 
 
 func IsEmptyNonPartitioned(ctx context.Context, h *HttpClient, t TopicRef) (bool, int64, error) {
-	s, err := getNonPartitionedStats(ctx, h, t)
+	s, err := GetNonPartitionedStats(ctx, h, t)
 	if err != nil {
 		return false, 0, err
 	}
-	backlog := sumBacklogFromStats(s)
+	backlog := s.TotalBacklog()
 	return backlog == 0, backlog, nil
 }
 
@@ -189,38 +298,16 @@ func IsEmptyPartitioned(ctx context.Context, h *HttpClient, t TopicRef) (bool, i
 	if err != nil {
 		return false, 0, err
 	}
-	var backlog int64
-
-	if v, ok := s["totalBacklog"]; ok {
-		switch x := v.(type) {
-		case float64:
-			backlog = int64(x)
-		case json.Number:
-			if i, err := x.Int64(); err == nil {
-				backlog = i
-			}
-		}
-	} else if pv, ok := s["partitions"]; ok {
-		if parts, ok := pv.(map[string]any); ok {
-			for _, sv := range parts {
-				if pm, ok := sv.(map[string]any); ok {
-					backlog += sumBacklogFromStats(pm)
-				}
-			}
-		}
-	} else {
-		backlog = sumBacklogFromStats(s)
-	}
-
+	backlog := s.TotalBacklog()
 	return backlog == 0, backlog, nil
 }
 
-func DeleteNonPartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef) error {
-	path := fmt.Sprintf("/persistent/%s/%s/%s",
-		url.PathEscape(t.Tenant),
-		url.PathEscape(t.Namespace),
-		url.PathEscape(t.Name),
-	)
+// DeleteNonPartitionedTopic удаляет топик; force — даже при подключённых продюсерах и консьюмерах.
+func DeleteNonPartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef, force bool) error {
+	path := topicPath(t)
+	if force {
+		path += "?force=true"
+	}
 	resp, err := h.req(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
@@ -233,12 +320,12 @@ func DeleteNonPartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef) e
 	return nil
 }
 
-func DeletePartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef) error {
-	path := fmt.Sprintf("/persistent/%s/%s/%s/partitions",
-		url.PathEscape(t.Tenant),
-		url.PathEscape(t.Namespace),
-		url.PathEscape(t.Name),
-	)
+// DeletePartitionedTopic удаляет топик; force — даже при подключённых продюсерах и консьюмерах.
+func DeletePartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef, force bool) error {
+	path := topicPath(t) + "/partitions"
+	if force {
+		path += "?force=true"
+	}
 	resp, err := h.req(ctx, "DELETE", path, nil)
 	if err != nil {
 		return err
@@ -265,131 +352,116 @@ func FilterTopicsByPrefix(topics []TopicRef, prefix string) []TopicRef {
 }
 
 func FetchNonPartitionedBacklogsParallel(
-    ctx context.Context,
-    h *HttpClient,
-    topics []TopicRef,
-    parallel int,
+	ctx context.Context,
+	h *HttpClient,
+	topics []TopicRef,
+	parallel int,
 ) []TopicBacklog {
-    if parallel <= 0 {
-        parallel = 8 // разумный дефолт
-    }
-    if parallel > len(topics) {
-        parallel = len(topics)
-    }
-    jobs := make(chan TopicRef)
-    results := make(chan TopicBacklog)
-
-    var wg sync.WaitGroup
-    wg.Add(parallel)
-
-    for i := 0; i < parallel; i++ {
-        go func() {
-            defer wg.Done()
-            for t := range jobs {
-                empty, backlog, err := IsEmptyNonPartitioned(ctx, h, t)
-                results <- TopicBacklog{
-                    Ref:     t,
-                    Backlog: backlog,
-                    Empty:   empty,
-                    Err:     err,
-                }
-            }
-        }()
-    }
-
-    go func() {
-        wg.Wait()
-        close(results)
-    }()
-
-    go func() {
-        defer close(jobs)
-        for _, t := range topics {
-            select {
-            case <-ctx.Done():
-                return
-            case jobs <- t:
-            }
-        }
-    }()
-
-    out := make([]TopicBacklog, 0, len(topics))
-    for r := range results {
-        out = append(out, r)
-    }
-    return out
+	return FetchBacklogsParallel(ctx, h, topics, KindNonPartitioned, parallel, nil)
 }
 
 func FetchPartitionedBacklogsParallel(
-    ctx context.Context,
-    h *HttpClient,
-    topics []TopicRef,
-    parallel int,
+	ctx context.Context,
+	h *HttpClient,
+	topics []TopicRef,
+	parallel int,
+) []TopicBacklog {
+	return FetchBacklogsParallel(ctx, h, topics, KindPartitioned, parallel, nil)
+}
+
+// FetchBacklogsParallel тянет stats топиков одного вида пулом из parallel
+// воркеров; onResult (если задан) вызывается на каждый результат из
+// одной горутины — удобно для прогресса.
+func FetchBacklogsParallel(
+	ctx context.Context,
+	h *HttpClient,
+	topics []TopicRef,
+	kind string,
+	parallel int,
+	onResult func(TopicBacklog),
 ) []TopicBacklog {
-    if parallel <= 0 {
-        parallel = 8
-    }
-    if parallel > len(topics) {
-        parallel = len(topics)
-    }
-    jobs := make(chan TopicRef)
-    results := make(chan TopicBacklog)
-
-    var wg sync.WaitGroup
-    wg.Add(parallel)
-
-    for i := 0; i < parallel; i++ {
-        go func() {
-            defer wg.Done()
-            for t := range jobs {
-                empty, backlog, err := IsEmptyPartitioned(ctx, h, t)
-                results <- TopicBacklog{
-                    Ref:     t,
-                    Backlog: backlog,
-                    Empty:   empty,
-                    Err:     err,
-                }
-            }
-        }()
-    }
-
-    go func() {
-        wg.Wait()
-        close(results)
-    }()
-
-    go func() {
-        defer close(jobs)
-        for _, t := range topics {
-            select {
-            case <-ctx.Done():
-                return
-            case jobs <- t:
-            }
-        }
-    }()
-
-    out := make([]TopicBacklog, 0, len(topics))
-    for r := range results {
-        out = append(out, r)
-    }
-    return out
+	if parallel <= 0 {
+		parallel = 8 // разумный дефолт
+	}
+	if parallel > len(topics) {
+		parallel = len(topics)
+	}
+	jobs := make(chan TopicRef)
+	results := make(chan TopicBacklog)
+
+	var wg sync.WaitGroup
+	wg.Add(parallel)
+
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				results <- fetchBacklog(ctx, h, t, kind)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(jobs)
+		for _, t := range topics {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- t:
+			}
+		}
+	}()
+
+	out := make([]TopicBacklog, 0, len(topics))
+	for r := range results {
+		if onResult != nil {
+			onResult(r)
+		}
+		out = append(out, r)
+	}
+	return out
 }
 
-// helpers
+func fetchBacklog(ctx context.Context, h *HttpClient, t TopicRef, kind string) TopicBacklog {
+	r := TopicBacklog{Ref: t}
+	if kind == KindPartitioned {
+		st, err := GetPartitionedStats(ctx, h, t)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Stats = &st.TopicStats
+		r.Backlog = st.TotalBacklog()
+	} else {
+		st, err := GetNonPartitionedStats(ctx, h, t)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Stats = st
+		r.Backlog = st.TotalBacklog()
+	}
+	r.Empty = r.Backlog == 0
+	return r
+}
 
 func parseFullTopicName(full string) (TopicRef, error) {
-	const prefix = "persistent://"
-	if !strings.HasPrefix(full, prefix) {
-		return TopicRef{}, fmt.Errorf("unsupported topic name format (expected persistent://...): %s", full)
+	domain, rest, ok := strings.Cut(full, "://")
+	if !ok || (domain != DomainPersistent && domain != DomainNonPersistent) {
+		return TopicRef{}, fmt.Errorf("unsupported topic name format (expected persistent:// or non-persistent://): %s", full)
 	}
-	rest := strings.TrimPrefix(full, prefix)
 	parts := strings.SplitN(rest, "/", 3)
 	if len(parts) != 3 {
 		return TopicRef{}, fmt.Errorf("invalid topic name: %s", full)
 	}
 	return TopicRef{
 		FullName:  full,
+		Domain:    domain,
 		Tenant:    parts[0],
 		Namespace: parts[1],
 		Name:      parts[2],
@@ -397,7 +469,7 @@ func parseFullTopicName(full string) (TopicRef, error) {
 }
 
 func ParseTopicArg(arg string, ctx *pulsarContext.Context) (TopicRef, error) {
-	if strings.HasPrefix(arg, "persistent://") {
+	if strings.Contains(arg, "://") {
 		return parseFullTopicName(arg)
 	}
 	if ctx.Tenant == "" || ctx.Namespace == "" {
@@ -406,35 +478,9 @@ func ParseTopicArg(arg string, ctx *pulsarContext.Context) (TopicRef, error) {
 	full := fmt.Sprintf("persistent://%s/%s/%s", ctx.Tenant, ctx.Namespace, arg)
 	return TopicRef{
 		FullName:  full,
+		Domain:    DomainPersistent,
 		Tenant:    ctx.Tenant,
 		Namespace: ctx.Namespace,
 		Name:      arg,
 	}, nil
 }
-
-func sumBacklogFromStats(stats map[string]any) int64 {
-	v, ok := stats["subscriptions"]
-	if !ok {
-		return 0
-	}
-	subs, ok := v.(map[string]any)
-	if !ok {
-		return 0
-	}
-	var total int64
-	for _, sv := range subs {
-		sub, ok := sv.(map[string]any)
-		if !ok {
-			continue
-		}
-		switch x := sub["msgBacklog"].(type) {
-		case float64:
-			total += int64(x)
-		case json.Number:
-			if i, err := x.Int64(); err == nil {
-				total += i
-			}
-		}
-	}
-	return total
-}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	pulsarContext "puls/cmd/ctx"
+)
+
+const maxRetryAfter = time.Minute
+
+// RetryPolicy описывает повторы запросов в HttpClient.req.
+type RetryPolicy struct {
+	MaxAttempts        int           // всего попыток, включая первую
+	InitialBackoff     time.Duration // пауза перед второй попыткой
+	MaxBackoff         time.Duration // потолок паузы
+	RetryNonIdempotent bool          // повторять и POST
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// RetryPolicyFromContext — дефолты, переопределённые полями контекста.
+func RetryPolicyFromContext(cx *pulsarContext.Context) RetryPolicy {
+	p := DefaultRetryPolicy()
+	if cx.RetryMaxAttempts > 0 {
+		p.MaxAttempts = cx.RetryMaxAttempts
+	}
+	if cx.RetryBackoffMs > 0 {
+		p.InitialBackoff = time.Duration(cx.RetryBackoffMs) * time.Millisecond
+	}
+	if cx.RetryMaxBackoffMs > 0 {
+		p.MaxBackoff = time.Duration(cx.RetryMaxBackoffMs) * time.Millisecond
+	}
+	p.RetryNonIdempotent = cx.RetryNonIdempotent
+	return p
+}
+
+func (p RetryPolicy) allows(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return p.RetryNonIdempotent
+}
+
+// backoff — «full jitter»: случайная пауза в [0, min(max, initial*2^n)].
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	// потолок — до сдвига: при большом attempt сдвиг переполняется
+	// и может дать положительное, но неверное значение
+	d := p.MaxBackoff
+	if attempt < 62 && p.InitialBackoff > 0 && p.InitialBackoff <= p.MaxBackoff>>attempt {
+		d = p.InitialBackoff << attempt
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d + 1)
+}
+
+// helpers
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableError — сетевые ошибки ретраим, отмену контекста и
+// ошибки проверки TLS-сертификата — нет (повтор не поможет).
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// parseRetryAfter понимает оба формата заголовка: секунды и HTTP-дату.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		// огромное число секунд переполнило бы Duration
+		d = time.Duration(min(secs, int(maxRetryAfter/time.Second))) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	} else {
+		return 0, false
+	}
+	if d < 0 {
+		d = 0
+	}
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d, true
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyAllows(t *testing.T) {
+	tests := []struct {
+		method        string
+		nonIdempotent bool
+		want          bool
+	}{
+		{"GET", false, true},
+		{"HEAD", false, true},
+		{"OPTIONS", false, true},
+		{"PUT", false, true},
+		{"DELETE", false, true},
+		{"POST", false, false},
+		{"PATCH", false, false},
+		{"POST", true, true},
+		{"PATCH", true, true},
+	}
+	for _, tt := range tests {
+		p := RetryPolicy{RetryNonIdempotent: tt.nonIdempotent}
+		if got := p.allows(tt.method); got != tt.want {
+			t.Errorf("allows(%s) with nonIdempotent=%v = %v, want %v", tt.method, tt.nonIdempotent, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffBounds(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{0, 200 * time.Millisecond},
+		{1, 400 * time.Millisecond},
+		{3, 1600 * time.Millisecond},
+		{5, 5 * time.Second},
+		{20, 5 * time.Second},
+		// 200ms<<40 переполняет int64 в положительное, но неверное значение
+		{40, 5 * time.Second},
+		{62, 5 * time.Second},
+		{63, 5 * time.Second},
+		{1000, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 200; i++ {
+			d := p.backoff(tt.attempt)
+			if d < 0 || d > tt.limit {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s]", tt.attempt, d, tt.limit)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyBackoffZero(t *testing.T) {
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Errorf("zero policy backoff = %s, want 0", d)
+	}
+	// без InitialBackoff пауза — до MaxBackoff
+	p := RetryPolicy{MaxBackoff: time.Second}
+	for i := 0; i < 100; i++ {
+		if d := p.backoff(0); d < 0 || d > time.Second {
+			t.Fatalf("backoff = %s, want within [0, 1s]", d)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"garbage", 0, false},
+		{"1.5", 0, false},
+		{"0", 0, true},
+		{"3", 3 * time.Second, true},
+		{"-5", 0, true},
+		{"120", maxRetryAfter, true},
+		{"99999999999999999", maxRetryAfter, true},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{now.Add(time.Hour).Format(http.TimeFormat), maxRetryAfter, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshot — что было у топиков перед деструктивной командой:
+// вид, число партиций, подписки с позициями курсоров и topic-level политики.
+// Пишется в JSON до удаления; по нему `puls restore` пересоздаёт топики.
+type Snapshot struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Context   string          `json:"context"`
+	AdminURL  string          `json:"admin_url"`
+	Command   string          `json:"command"`
+	Topics    []TopicSnapshot `json:"topics"`
+}
+
+const SnapshotVersion = 1
+
+type TopicSnapshot struct {
+	Topic         string                     `json:"topic"` // persistent://tenant/ns/name или non-persistent://...
+	Kind          string                     `json:"kind"`  // KindNonPartitioned | KindPartitioned
+	Partitions    int                        `json:"partitions"`
+	Subscriptions []SubscriptionSnapshot     `json:"subscriptions"`
+	Policies      map[string]json.RawMessage `json:"policies,omitempty"`
+}
+
+type SubscriptionSnapshot struct {
+	Name    string           `json:"name"`
+	Cursors []CursorSnapshot `json:"cursors"`
+}
+
+// CursorSnapshot — позиция курсора; Partition = -1 для non-partitioned.
+type CursorSnapshot struct {
+	Partition          int    `json:"partition"`
+	MarkDeletePosition string `json:"mark_delete_position"`
+	ReadPosition       string `json:"read_position"`
+}
+
+// SnapshotPolicies — topic-level политики, которые сохраняются и
+// восстанавливаются (GET/POST с JSON-телом по одному и тому же пути).
+var SnapshotPolicies = []string{
+	"retention",
+	"messageTTL",
+	"maxProducers",
+	"maxConsumers",
+	"maxUnackedMessagesOnConsumer",
+	"maxUnackedMessagesOnSubscription",
+	"deduplicationEnabled",
+	"compactionThreshold",
+	"delayedDelivery",
+	"inactiveTopicPolicies",
+	"persistence",
+	"publishRate",
+	"dispatchRate",
+	"subscriptionDispatchRate",
+}
+
+// SnapshotTopic собирает снимок одного топика.
+func SnapshotTopic(ctx context.Context, h *HttpClient, t TopicRef, kind string) (TopicSnapshot, error) {
+	ts := TopicSnapshot{Topic: t.FullName, Kind: kind}
+	subs := map[string][]CursorSnapshot{}
+	switch {
+	case !t.IsPersistent():
+		// у non-persistent нет курсоров в хранилище — только имена подписок
+		var st *TopicStats
+		if kind == KindPartitioned {
+			ps, err := GetPartitionedStats(ctx, h, t)
+			if err != nil {
+				return ts, err
+			}
+			ts.Partitions = ps.Metadata.Partitions
+			st = &ps.TopicStats
+		} else {
+			var err error
+			if st, err = GetNonPartitionedStats(ctx, h, t); err != nil {
+				return ts, err
+			}
+		}
+		for sub := range st.Subscriptions {
+			subs[sub] = nil
+		}
+	case kind == KindPartitioned:
+		st, err := GetPartitionedInternalStats(ctx, h, t)
+		if err != nil {
+			return ts, err
+		}
+		ts.Partitions = st.Metadata.Partitions
+		for name, p := range st.Partitions {
+			idx := partitionIndex(name)
+			for sub, c := range p.Cursors {
+				subs[sub] = append(subs[sub], CursorSnapshot{Partition: idx, MarkDeletePosition: c.MarkDeletePosition, ReadPosition: c.ReadPosition})
+			}
+		}
+	default:
+		st, err := GetInternalStats(ctx, h, t)
+		if err != nil {
+			return ts, err
+		}
+		for sub, c := range st.Cursors {
+			subs[sub] = append(subs[sub], CursorSnapshot{Partition: -1, MarkDeletePosition: c.MarkDeletePosition, ReadPosition: c.ReadPosition})
+		}
+	}
+	for name, cursors := range subs {
+		sort.Slice(cursors, func(i, j int) bool { return cursors[i].Partition < cursors[j].Partition })
+		ts.Subscriptions = append(ts.Subscriptions, SubscriptionSnapshot{Name: name, Cursors: cursors})
+	}
+	sort.Slice(ts.Subscriptions, func(i, j int) bool { return ts.Subscriptions[i].Name < ts.Subscriptions[j].Name })
+
+	for _, name := range SnapshotPolicies {
+		raw, ok, err := GetTopicPolicy(ctx, h, t, name)
+		if err != nil {
+			return ts, err
+		}
+		if ok {
+			if ts.Policies == nil {
+				ts.Policies = map[string]json.RawMessage{}
+			}
+			ts.Policies[name] = raw
+		}
+	}
+	return ts, nil
+}
+
+// GetTopicPolicy — значение topic-level политики; ok=false, если она не
+// задана или topic-level политики выключены на брокере.
+func GetTopicPolicy(ctx context.Context, h *HttpClient, t TopicRef, name string) (json.RawMessage, bool, error) {
+	resp, err := h.req(ctx, "GET", topicPath(t)+"/"+name, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case 200:
+	case 204, 404, 405:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("policy %s %s: %s (%s)", name, t.FullName, resp.Status, string(b))
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || string(b) == "null" {
+		return nil, false, nil
+	}
+	return json.RawMessage(b), true, nil
+}
+
+func SetTopicPolicy(ctx context.Context, h *HttpClient, t TopicRef, name string, value json.RawMessage) error {
+	path := topicPath(t) + "/" + name
+	if name == "messageTTL" {
+		// messageTTL принимается только query-параметром
+		path += "?messageTTL=" + url.QueryEscape(string(value))
+		return h.expectOK(ctx, "POST", path, nil, "set policy "+name+" "+t.FullName)
+	}
+	return h.expectOK(ctx, "POST", path, bytes.NewReader(value), "set policy "+name+" "+t.FullName)
+}
+
+// CreateNonPartitionedTopic создаёт топик; exists=true, если он уже был.
+func CreateNonPartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef) (exists bool, err error) {
+	return h.expectCreated(ctx, topicPath(t), nil, "create topic "+t.FullName)
+}
+
+func CreatePartitionedTopic(ctx context.Context, h *HttpClient, t TopicRef, partitions int) (exists bool, err error) {
+	body := strings.NewReader(strconv.Itoa(partitions))
+	return h.expectCreated(ctx, topicPath(t)+"/partitions", body, "create partitioned topic "+t.FullName)
+}
+
+// CreateSubscription создаёт подписку на позиции id
+// (LatestMessageID/EarliestMessageID или конкретное сообщение).
+func CreateSubscription(ctx context.Context, h *HttpClient, t TopicRef, sub string, id MessageID) (exists bool, err error) {
+	b, err := json.Marshal(id)
+	if err != nil {
+		return false, err
+	}
+	return h.expectCreated(ctx, subscriptionPath(t, sub), bytes.NewReader(b), "create subscription "+t.FullName+" "+sub)
+}
+
+// позиции для CreateSubscription, как MessageId.latest/earliest в Pulsar
+var (
+	LatestMessageID   = MessageID{LedgerID: 9223372036854775807, EntryID: 9223372036854775807, PartitionIndex: -1}
+	EarliestMessageID = MessageID{LedgerID: -1, EntryID: -1, PartitionIndex: -1}
+)
+
+// ParsePosition разбирает "ledgerId:entryId" — позицию курсора или
+// --message-id у reset-cursor.
+func ParsePosition(s string) (MessageID, error) {
+	l, e, ok := strings.Cut(s, ":")
+	if !ok {
+		return MessageID{}, fmt.Errorf("bad position %q: expected <ledgerId>:<entryId>", s)
+	}
+	ledger, err := strconv.ParseInt(l, 10, 64)
+	if err != nil {
+		return MessageID{}, fmt.Errorf("bad ledger id in %q: %w", s, err)
+	}
+	entry, err := strconv.ParseInt(e, 10, 64)
+	if err != nil {
+		return MessageID{}, fmt.Errorf("bad entry id in %q: %w", s, err)
+	}
+	return MessageID{LedgerID: ledger, EntryID: entry, PartitionIndex: -1}, nil
+}
+
+// PartitionRef — ссылка на одну партицию partitioned-топика.
+func PartitionRef(t TopicRef, i int) TopicRef {
+	name := fmt.Sprintf("%s-partition-%d", t.Name, i)
+	return TopicRef{
+		Domain:    t.Domain,
+		Tenant:    t.Tenant,
+		Namespace: t.Namespace,
+		Name:      name,
+		FullName:  fmt.Sprintf("%s://%s/%s/%s", t.domain(), t.Tenant, t.Namespace, name),
+	}
+}
+
+func WriteSnapshot(path string, s *Snapshot) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+func ReadSnapshot(path string) (*Snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("snapshot %s: unsupported version %d", path, s.Version)
+	}
+	return &s, nil
+}
+
+// helpers
+
+// expectCreated — 2xx создано, 409 уже существует.
+func (h *HttpClient) expectCreated(ctx context.Context, path string, body io.Reader, what string) (bool, error) {
+	resp, err := h.req(ctx, "PUT", path, body)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 409 {
+		return true, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("%s: %s (%s)", what, resp.Status, string(b))
+	}
+	return false, nil
+}
+
+// partitionIndex — N из "...-partition-N", -1 если суффикса нет.
+func partitionIndex(name string) int {
+	i := strings.LastIndex(name, "-partition-")
+	if i < 0 {
+		return -1
+	}
+	n, err := strconv.Atoi(name[i+len("-partition-"):])
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Типизированная модель ответов /stats и /partitioned-stats (admin v2).
+// Поля, которых нет в структурах (новые версии брокера), сохраняются в Extra
+// и возвращаются обратно при сериализации.
+
+type PublisherStats struct {
+	AccessMode              string            `json:"accessMode,omitempty"`
+	MsgRateIn               float64           `json:"msgRateIn"`
+	MsgThroughputIn         float64           `json:"msgThroughputIn"`
+	AverageMsgSize          float64           `json:"averageMsgSize"`
+	ChunkedMessageRate      float64           `json:"chunkedMessageRate"`
+	ProducerID              int64             `json:"producerId"`
+	SupportsPartialProducer bool              `json:"supportsPartialProducer"`
+	ProducerName            string            `json:"producerName"`
+	Address                 string            `json:"address"`
+	ConnectedSince          string            `json:"connectedSince"`
+	ClientVersion           string            `json:"clientVersion"`
+	Metadata                map[string]string `json:"metadata,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type ConsumerStats struct {
+	MsgRateOut                   float64           `json:"msgRateOut"`
+	MsgThroughputOut             float64           `json:"msgThroughputOut"`
+	BytesOutCounter              int64             `json:"bytesOutCounter"`
+	MsgOutCounter                int64             `json:"msgOutCounter"`
+	MsgRateRedeliver             float64           `json:"msgRateRedeliver"`
+	ChunkedMessageRate           float64           `json:"chunkedMessageRate"`
+	ConsumerName                 string            `json:"consumerName"`
+	AvailablePermits             int64             `json:"availablePermits"`
+	UnackedMessages              int64             `json:"unackedMessages"`
+	AvgMessagesPerEntry          int64             `json:"avgMessagesPerEntry"`
+	BlockedConsumerOnUnackedMsgs bool              `json:"blockedConsumerOnUnackedMsgs"`
+	ReadPositionWhenJoining      string            `json:"readPositionWhenJoining,omitempty"`
+	Address                      string            `json:"address"`
+	ConnectedSince               string            `json:"connectedSince"`
+	ClientVersion                string            `json:"clientVersion"`
+	LastAckedTimestamp           int64             `json:"lastAckedTimestamp"`
+	LastConsumedTimestamp        int64             `json:"lastConsumedTimestamp"`
+	LastConsumedFlowTimestamp    int64             `json:"lastConsumedFlowTimestamp"`
+	Metadata                     map[string]string `json:"metadata,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type SubscriptionStats struct {
+	MsgRateOut                       float64           `json:"msgRateOut"`
+	MsgThroughputOut                 float64           `json:"msgThroughputOut"`
+	BytesOutCounter                  int64             `json:"bytesOutCounter"`
+	MsgOutCounter                    int64             `json:"msgOutCounter"`
+	MsgRateRedeliver                 float64           `json:"msgRateRedeliver"`
+	ChunkedMessageRate               float64           `json:"chunkedMessageRate"`
+	MsgBacklog                       int64             `json:"msgBacklog"`
+	BacklogSize                      int64             `json:"backlogSize"`
+	EarliestMsgPublishTimeInBacklog  int64             `json:"earliestMsgPublishTimeInBacklog"`
+	MsgBacklogNoDelayed              int64             `json:"msgBacklogNoDelayed"`
+	BlockedSubscriptionOnUnackedMsgs bool              `json:"blockedSubscriptionOnUnackedMsgs"`
+	MsgDelayed                       int64             `json:"msgDelayed"`
+	UnackedMessages                  int64             `json:"unackedMessages"`
+	Type                             string            `json:"type"`
+	ActiveConsumerName               string            `json:"activeConsumerName,omitempty"`
+	MsgRateExpired                   float64           `json:"msgRateExpired"`
+	TotalMsgExpired                  int64             `json:"totalMsgExpired"`
+	LastExpireTimestamp              int64             `json:"lastExpireTimestamp"`
+	LastConsumedFlowTimestamp        int64             `json:"lastConsumedFlowTimestamp"`
+	LastConsumedTimestamp            int64             `json:"lastConsumedTimestamp"`
+	LastAckedTimestamp               int64             `json:"lastAckedTimestamp"`
+	LastMarkDeleteAdvancedTimestamp  int64             `json:"lastMarkDeleteAdvancedTimestamp"`
+	Consumers                        []ConsumerStats   `json:"consumers"`
+	IsDurable                        bool              `json:"isDurable"`
+	IsReplicated                     bool              `json:"isReplicated"`
+	AllowOutOfOrderDelivery          bool              `json:"allowOutOfOrderDelivery"`
+	SubscriptionProperties           map[string]string `json:"subscriptionProperties,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type TopicStats struct {
+	MsgRateIn                        float64                      `json:"msgRateIn"`
+	MsgRateOut                       float64                      `json:"msgRateOut"`
+	MsgThroughputIn                  float64                      `json:"msgThroughputIn"`
+	MsgThroughputOut                 float64                      `json:"msgThroughputOut"`
+	BytesInCounter                   int64                        `json:"bytesInCounter"`
+	MsgInCounter                     int64                        `json:"msgInCounter"`
+	BytesOutCounter                  int64                        `json:"bytesOutCounter"`
+	MsgOutCounter                    int64                        `json:"msgOutCounter"`
+	AverageMsgSize                   float64                      `json:"averageMsgSize"`
+	MsgChunkPublished                bool                         `json:"msgChunkPublished"`
+	StorageSize                      int64                        `json:"storageSize"`
+	BacklogSize                      int64                        `json:"backlogSize"`
+	EarliestMsgPublishTimeInBacklogs int64                        `json:"earliestMsgPublishTimeInBacklogs"`
+	OffloadedStorageSize             int64                        `json:"offloadedStorageSize"`
+	Publishers                       []PublisherStats             `json:"publishers"`
+	WaitingPublishers                int64                        `json:"waitingPublishers"`
+	Subscriptions                    map[string]SubscriptionStats `json:"subscriptions"`
+	Replication                      map[string]json.RawMessage   `json:"replication,omitempty"`
+	DeduplicationStatus              string                       `json:"deduplicationStatus,omitempty"`
+	TopicEpoch                       *int64                       `json:"topicEpoch,omitempty"`
+	NonContiguousDeletedMsgRanges    int64                        `json:"nonContiguousDeletedMessagesRanges"`
+	OwnerBroker                      string                       `json:"ownerBroker,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type PartitionedTopicMetadata struct {
+	Partitions int `json:"partitions"`
+}
+
+// PartitionedTopicStats — агрегированные stats по всем партициям
+// плюс (при perPartition=true, это дефолт брокера) stats каждой партиции.
+type PartitionedTopicStats struct {
+	TopicStats
+	Metadata   PartitionedTopicMetadata `json:"metadata"`
+	Partitions map[string]TopicStats    `json:"partitions,omitempty"`
+}
+
+// TotalBacklog — сумма msgBacklog по всем подпискам.
+func (s *TopicStats) TotalBacklog() int64 {
+	var total int64
+	for _, sub := range s.Subscriptions {
+		total += sub.MsgBacklog
+	}
+	return total
+}
+
+// ConsumerCount — число подключённых консьюмеров по всем подпискам.
+func (s *TopicStats) ConsumerCount() int {
+	n := 0
+	for _, sub := range s.Subscriptions {
+		n += len(sub.Consumers)
+	}
+	return n
+}
+
+// TotalBacklog для partitioned: по партициям, если брокер их вернул,
+// иначе по агрегированным подпискам.
+func (s *PartitionedTopicStats) TotalBacklog() int64 {
+	if raw, ok := s.Extra["totalBacklog"]; ok {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err == nil {
+			return int64(v)
+		}
+	}
+	if len(s.Partitions) > 0 {
+		var total int64
+		for _, p := range s.Partitions {
+			total += p.TotalBacklog()
+		}
+		return total
+	}
+	return s.TopicStats.TotalBacklog()
+}
+
+func (s *PublisherStats) UnmarshalJSON(b []byte) error {
+	type plain PublisherStats
+	extra, err := decodeWithExtra(b, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s PublisherStats) MarshalJSON() ([]byte, error) {
+	type plain PublisherStats
+	return encodeWithExtra(plain(s), s.Extra)
+}
+
+func (s *ConsumerStats) UnmarshalJSON(b []byte) error {
+	type plain ConsumerStats
+	extra, err := decodeWithExtra(b, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s ConsumerStats) MarshalJSON() ([]byte, error) {
+	type plain ConsumerStats
+	return encodeWithExtra(plain(s), s.Extra)
+}
+
+func (s *SubscriptionStats) UnmarshalJSON(b []byte) error {
+	type plain SubscriptionStats
+	extra, err := decodeWithExtra(b, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s SubscriptionStats) MarshalJSON() ([]byte, error) {
+	type plain SubscriptionStats
+	return encodeWithExtra(plain(s), s.Extra)
+}
+
+func (s *TopicStats) UnmarshalJSON(b []byte) error {
+	type plain TopicStats
+	extra, err := decodeWithExtra(b, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s TopicStats) MarshalJSON() ([]byte, error) {
+	type plain TopicStats
+	return encodeWithExtra(plain(s), s.Extra)
+}
+
+// TopicStats встроен, поэтому его (Un)MarshalJSON «всплыл» бы наверх
+// и потерял metadata/partitions — разбираем в два прохода.
+func (s *PartitionedTopicStats) UnmarshalJSON(b []byte) error {
+	if err := s.TopicStats.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	var rest struct {
+		Metadata   PartitionedTopicMetadata `json:"metadata"`
+		Partitions map[string]TopicStats    `json:"partitions"`
+	}
+	if err := json.Unmarshal(b, &rest); err != nil {
+		return err
+	}
+	s.Metadata = rest.Metadata
+	s.Partitions = rest.Partitions
+	delete(s.Extra, "metadata")
+	delete(s.Extra, "partitions")
+	if len(s.Extra) == 0 {
+		s.Extra = nil
+	}
+	return nil
+}
+
+func (s PartitionedTopicStats) MarshalJSON() ([]byte, error) {
+	extra := make(map[string]json.RawMessage, len(s.Extra)+2)
+	for k, v := range s.Extra {
+		extra[k] = v
+	}
+	md, err := json.Marshal(s.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	extra["metadata"] = md
+	if s.Partitions != nil {
+		parts, err := json.Marshal(s.Partitions)
+		if err != nil {
+			return nil, err
+		}
+		extra["partitions"] = parts
+	}
+	type plain TopicStats
+	return encodeWithExtra(plain(s.TopicStats), extra)
+}
+
+// helpers
+
+// decodeWithExtra разбирает b в dst (указатель на тип без собственного
+// UnmarshalJSON) и возвращает поля, которых нет в json-тегах dst.
+func decodeWithExtra(b []byte, dst any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(b, dst); err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	known := jsonFieldNames(reflect.TypeOf(dst).Elem())
+	for k := range all {
+		if known[k] {
+			delete(all, k)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// encodeWithExtra сериализует v и дописывает неизвестные поля из extra.
+func encodeWithExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, raw := range extra {
+		if _, ok := m[k]; !ok {
+			m[k] = raw
+		}
+	}
+	return json.Marshal(m)
+}
+
+var jsonFieldCache sync.Map // reflect.Type -> map[string]bool
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	if v, ok := jsonFieldCache.Load(t); ok {
+		return v.(map[string]bool)
+	}
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				for k := range jsonFieldNames(f.Type) {
+					names[k] = true
+				}
+				continue
+			}
+			name = f.Name
+		}
+		names[name] = true
+	}
+	jsonFieldCache.Store(t, names)
+	return names
+}
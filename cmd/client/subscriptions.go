@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+type SubscriptionInfo struct {
+	Name      string
+	Type      string
+	Backlog   int64
+	Unacked   int64
+	Consumers int
+	RateOut   float64
+	Stats     SubscriptionStats
+}
+
+// MessageID — позиция в топике (ledger:entry), как в admin API.
+type MessageID struct {
+	LedgerID       int64 `json:"ledgerId"`
+	EntryID        int64 `json:"entryId"`
+	PartitionIndex int   `json:"partitionIndex"`
+}
+
+func GetPartitionCount(ctx context.Context, h *HttpClient, t TopicRef) (int, error) {
+	resp, err := h.req(ctx, "GET", topicPath(t)+"/partitions", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("partitions %s: %s (%s)", t.FullName, resp.Status, string(b))
+	}
+	var md PartitionedTopicMetadata
+	if err := json.Unmarshal(b, &md); err != nil {
+		return 0, err
+	}
+	return md.Partitions, nil
+}
+
+// GetTopicStats возвращает stats топика любого вида; для partitioned —
+// агрегированные по всем партициям.
+func GetTopicStats(ctx context.Context, h *HttpClient, t TopicRef) (*TopicStats, int, error) {
+	n, err := GetPartitionCount(ctx, h, t)
+	if err != nil {
+		return nil, 0, err
+	}
+	if n > 0 {
+		ps, err := GetPartitionedStats(ctx, h, t)
+		if err != nil {
+			return nil, n, err
+		}
+		return &ps.TopicStats, n, nil
+	}
+	s, err := GetNonPartitionedStats(ctx, h, t)
+	return s, 0, err
+}
+
+func ListSubscriptions(ctx context.Context, h *HttpClient, t TopicRef) ([]SubscriptionInfo, error) {
+	s, _, err := GetTopicStats(ctx, h, t)
+	if err != nil {
+		return nil, err
+	}
+	return SubscriptionInfos(s), nil
+}
+
+// SubscriptionInfos — сводка по подпискам из stats, отсортированная по имени.
+func SubscriptionInfos(s *TopicStats) []SubscriptionInfo {
+	out := make([]SubscriptionInfo, 0, len(s.Subscriptions))
+	for name, sub := range s.Subscriptions {
+		out = append(out, SubscriptionInfo{
+			Name:      name,
+			Type:      sub.Type,
+			Backlog:   sub.MsgBacklog,
+			Unacked:   sub.UnackedMessages,
+			Consumers: len(sub.Consumers),
+			RateOut:   sub.MsgRateOut,
+			Stats:     sub,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func ClearSubscriptionBacklog(ctx context.Context, h *HttpClient, t TopicRef, sub string) error {
+	path := subscriptionPath(t, sub) + "/skip_all"
+	return h.expectOK(ctx, "POST", path, nil, "clear backlog "+t.FullName+" "+sub)
+}
+
+func SkipSubscriptionMessages(ctx context.Context, h *HttpClient, t TopicRef, sub string, n int64) error {
+	path := subscriptionPath(t, sub) + "/skip/" + strconv.FormatInt(n, 10)
+	return h.expectOK(ctx, "POST", path, nil, "skip "+t.FullName+" "+sub)
+}
+
+// ResetCursorToTime переводит курсор на первое сообщение, опубликованное
+// не раньше tsMillis (unix ms).
+func ResetCursorToTime(ctx context.Context, h *HttpClient, t TopicRef, sub string, tsMillis int64) error {
+	path := subscriptionPath(t, sub) + "/resetcursor/" + strconv.FormatInt(tsMillis, 10)
+	return h.expectOK(ctx, "POST", path, nil, "reset-cursor "+t.FullName+" "+sub)
+}
+
+func ResetCursorToMessageID(ctx context.Context, h *HttpClient, t TopicRef, sub string, id MessageID) error {
+	b, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	path := subscriptionPath(t, sub) + "/resetcursor"
+	return h.expectOK(ctx, "POST", path, bytes.NewReader(b), "reset-cursor "+t.FullName+" "+sub)
+}
+
+func DeleteSubscription(ctx context.Context, h *HttpClient, t TopicRef, sub string, force bool) error {
+	path := subscriptionPath(t, sub)
+	if force {
+		path += "?force=true"
+	}
+	return h.expectOK(ctx, "DELETE", path, nil, "delete subscription "+t.FullName+" "+sub)
+}
+
+// helpers
+
+func topicPath(t TopicRef) string {
+	return fmt.Sprintf("/%s/%s/%s/%s",
+		t.domain(),
+		url.PathEscape(t.Tenant),
+		url.PathEscape(t.Namespace),
+		url.PathEscape(t.Name),
+	)
+}
+
+func subscriptionPath(t TopicRef, sub string) string {
+	return topicPath(t) + "/subscription/" + url.PathEscape(sub)
+}
+
+// expectOK выполняет запрос и считает успехом любой 2xx.
+func (h *HttpClient) expectOK(ctx context.Context, method, path string, body io.Reader, what string) error {
+	resp, err := h.req(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s (%s)", what, resp.Status, string(b))
+	}
+	return nil
+}
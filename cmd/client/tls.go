@@ -0,0 +1,63 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	pulsarContext "puls/cmd/ctx"
+)
+
+// buildTransport собирает http.Transport с TLS-настройками контекста.
+// Если TLS в контексте не настроен, возвращает nil (дефолтный транспорт).
+func buildTransport(cx *pulsarContext.Context) (*http.Transport, error) {
+	if cx.TLSCAFile == "" && cx.TLSCertFile == "" && cx.TLSKeyFile == "" &&
+		!cx.TLSInsecureSkipVerify && cx.TLSServerName == "" {
+		return nil, nil
+	}
+	cfg, err := buildTLSConfig(cx)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = cfg
+	return tr, nil
+}
+
+func buildTLSConfig(cx *pulsarContext.Context) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cx.TLSServerName,
+		InsecureSkipVerify: cx.TLSInsecureSkipVerify,
+	}
+
+	if cx.TLSCAFile != "" {
+		pem, err := os.ReadFile(cx.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls_ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls_ca_file %s: no PEM certificates found", cx.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (cx.TLSCertFile == "") != (cx.TLSKeyFile == "") {
+		return nil, errors.New("tls_cert_file and tls_key_file must be set together")
+	}
+	if cx.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cx.TLSCertFile, cx.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
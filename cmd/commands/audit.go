@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+)
+
+// auditListFlags — флаги audit list.
+type auditListFlags struct {
+	g                                     *globalFlags
+	since, userName, method, output, file string
+}
+
+func bindAuditListFlags(fs *flag.FlagSet) *auditListFlags {
+	o := &auditListFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.StringVar(&o.since, "since", "24h", "show entries newer than this: duration (24h) or RFC3339 time, \"\" = all")
+	fs.StringVar(&o.userName, "user", "", "only entries of this OS user")
+	fs.StringVar(&o.method, "method", "", "only this HTTP method (POST, PUT, DELETE)")
+	fs.StringVar(&o.output, "output", outputTable, "output format: table, json, jsonl")
+	fs.StringVar(&o.file, "file", "", "audit log file (default: audit_log of the context)")
+	return o
+}
+
+func auditList(args []string) error {
+	fs := newFlagSet("audit list")
+	o := bindAuditListFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	switch o.output {
+	case outputTable, outputJSON, outputJSONL:
+	default:
+		return usageErrorf("unknown --output %q (supported: table, json, jsonl)", o.output)
+	}
+	var from time.Time
+	if o.since != "" {
+		t, err := parseResetTime(o.since, time.Now())
+		if err != nil {
+			return usageErrorf("--since: %v", err)
+		}
+		from = t
+	}
+	if o.file == "" {
+		cx, err := o.g.loadContext()
+		if err != nil {
+			return err
+		}
+		if o.file, err = pulsarClient.AuditPath(cx); err != nil {
+			return err
+		}
+	}
+
+	entries, err := pulsarClient.ReadAuditLog(o.file, from)
+	if err != nil {
+		return err
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if o.userName != "" && e.User != o.userName && e.SudoUser != o.userName {
+			continue
+		}
+		if o.method != "" && !strings.EqualFold(e.Method, o.method) {
+			continue
+		}
+		out = append(out, e)
+	}
+
+	switch o.output {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if out == nil {
+			out = []pulsarClient.AuditEntry{}
+		}
+		return enc.Encode(out)
+	case outputJSONL:
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range out {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(out) == 0 {
+		fmt.Printf("no audit entries in %s\n", o.file)
+		return nil
+	}
+	printAuditTable(out)
+	return nil
+}
+
+// helpers
+
+func printAuditTable(entries []pulsarClient.AuditEntry) {
+	userLen, ctxLen, pathLen := len("USER"), len("CONTEXT"), len("PATH")
+	for _, e := range entries {
+		userLen = max(userLen, len(auditUser(e)))
+		ctxLen = max(ctxLen, len(e.Context))
+		pathLen = max(pathLen, len(e.Path))
+	}
+	fmt.Printf("%-20s | %-*s | %-*s | %-6s | %6s | %-*s | %s\n",
+		"TIME", userLen, "USER", ctxLen, "CONTEXT", "METHOD", "STATUS", pathLen, "PATH", "COMMAND")
+	fmt.Printf("%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s\n",
+		strings.Repeat("-", 20),
+		strings.Repeat("-", userLen),
+		strings.Repeat("-", ctxLen),
+		strings.Repeat("-", 6),
+		strings.Repeat("-", 6),
+		strings.Repeat("-", pathLen),
+		strings.Repeat("-", 7),
+	)
+	for _, e := range entries {
+		status := strconv.Itoa(e.Status)
+		if e.Status == 0 {
+			status = "error"
+		}
+		fmt.Printf("%-20s | %-*s | %-*s | %-6s | %6s | %-*s | %s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"),
+			userLen, auditUser(e),
+			ctxLen, e.Context,
+			e.Method,
+			status,
+			pathLen, e.Path,
+			e.Command,
+		)
+	}
+}
+
+func auditUser(e pulsarClient.AuditEntry) string {
+	if e.SudoUser != "" {
+		return e.User + " (sudo " + e.SudoUser + ")"
+	}
+	return e.User
+}
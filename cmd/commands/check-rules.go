@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	pulsarClient "puls/cmd/client"
+)
+
+// уровни правил
+const (
+	levelWarning  = "warning"
+	levelCritical = "critical"
+)
+
+// виды правил
+const (
+	ruleBacklog     = "backlog"
+	ruleNoConsumers = "no-consumers"
+	ruleGrowing     = "growing"
+)
+
+var (
+	reRuleBacklog     = regexp.MustCompile(`^backlog\s*(>=|>)\s*([0-9_]+)$`)
+	reRuleNoConsumers = regexp.MustCompile(`^no[- ]consumers(\s+on\s+subscriptions?)?$`)
+	reRuleGrowing     = regexp.MustCompile(`^(?:backlog\s+)?growing(?:\s+for)?\s+([0-9]+)(?:\s+samples?)?$`)
+)
+
+// checkRule — правило из флагов или файла. Rule — выражение:
+//
+//	backlog > N        бэклог топика (или подписки, если задан subscription)
+//	no-consumers       у подписки нет консьюмеров
+//	growing N          бэклог рос N сэмплов подряд
+type checkRule struct {
+	Name         string `json:"name,omitempty"`
+	Level        string `json:"level"`
+	Rule         string `json:"rule"`
+	Prefix       string `json:"prefix,omitempty"`
+	Regex        string `json:"regex,omitempty"`
+	Subscription string `json:"subscription,omitempty"`
+
+	kind      string
+	inclusive bool // >= вместо >
+	threshold int64
+	samples   int
+	re        *regexp.Regexp
+	subRe     *regexp.Regexp
+}
+
+type checkRulesFile struct {
+	Rules []*checkRule `json:"rules"`
+}
+
+func (r *checkRule) compile() error {
+	label := r.label()
+	switch r.Level {
+	case "", levelWarning, "warn":
+		r.Level = levelWarning
+	case levelCritical, "crit":
+		r.Level = levelCritical
+	default:
+		return fmt.Errorf("rule %s: unknown level %q (warning, critical)", label, r.Level)
+	}
+
+	expr := strings.ToLower(strings.Join(strings.Fields(r.Rule), " "))
+	if m := reRuleBacklog.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.ParseInt(strings.ReplaceAll(m[2], "_", ""), 10, 64)
+		if err != nil {
+			return fmt.Errorf("rule %s: %v", label, err)
+		}
+		r.kind, r.threshold, r.inclusive = ruleBacklog, n, m[1] == ">="
+	} else if reRuleNoConsumers.MatchString(expr) {
+		r.kind = ruleNoConsumers
+	} else if m := reRuleGrowing.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 {
+			return fmt.Errorf("rule %s: growing needs at least 1 sample", label)
+		}
+		r.kind, r.samples = ruleGrowing, n
+	} else {
+		return fmt.Errorf(`rule %s: cannot parse %q (expected "backlog > N", "no-consumers" or "growing N")`, label, r.Rule)
+	}
+
+	var err error
+	if r.Regex != "" {
+		if r.re, err = regexp.Compile(r.Regex); err != nil {
+			return fmt.Errorf("rule %s: regex: %v", label, err)
+		}
+	}
+	if r.Subscription != "" {
+		if r.subRe, err = regexp.Compile(r.Subscription); err != nil {
+			return fmt.Errorf("rule %s: subscription: %v", label, err)
+		}
+	}
+	return nil
+}
+
+func (r *checkRule) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return strconv.Quote(r.Rule)
+}
+
+func (r *checkRule) matchTopic(ref pulsarClient.TopicRef) bool {
+	if r.Prefix != "" && !strings.HasPrefix(ref.Name, r.Prefix) {
+		return false
+	}
+	return r.re == nil || matchTopicName(r.re, ref)
+}
+
+func (r *checkRule) matchSubscription(name string) bool {
+	return r.subRe == nil || r.subRe.MatchString(name)
+}
+
+func (r *checkRule) evaluate(tb pulsarClient.TopicBacklog, history []checkSample) []checkFinding {
+	finding := func(sub string, value int64, msg string) checkFinding {
+		return checkFinding{
+			Level:        r.Level,
+			Rule:         r.Rule,
+			Topic:        tb.Ref.FullName,
+			Subscription: sub,
+			Value:        value,
+			Message:      msg,
+		}
+	}
+	over := func(v int64) bool {
+		if r.inclusive {
+			return v >= r.threshold
+		}
+		return v > r.threshold
+	}
+	op := ">"
+	if r.inclusive {
+		op = ">="
+	}
+
+	var out []checkFinding
+	switch r.kind {
+	case ruleBacklog:
+		if r.subRe == nil {
+			if over(tb.Backlog) {
+				out = append(out, finding("", tb.Backlog,
+					fmt.Sprintf("%s backlog %d %s %d", tb.Ref.Name, tb.Backlog, op, r.threshold)))
+			}
+			break
+		}
+		for _, name := range sortedSubscriptions(tb.Stats) {
+			sub := tb.Stats.Subscriptions[name]
+			if r.matchSubscription(name) && over(sub.MsgBacklog) {
+				out = append(out, finding(name, sub.MsgBacklog,
+					fmt.Sprintf("%s/%s backlog %d %s %d", tb.Ref.Name, name, sub.MsgBacklog, op, r.threshold)))
+			}
+		}
+
+	case ruleNoConsumers:
+		for _, name := range sortedSubscriptions(tb.Stats) {
+			sub := tb.Stats.Subscriptions[name]
+			if r.matchSubscription(name) && len(sub.Consumers) == 0 {
+				out = append(out, finding(name, sub.MsgBacklog,
+					fmt.Sprintf("%s/%s has no consumers (backlog %d)", tb.Ref.Name, name, sub.MsgBacklog)))
+			}
+		}
+
+	case ruleGrowing:
+		if len(history) < r.samples+1 {
+			break
+		}
+		series := history[len(history)-r.samples-1:]
+		growing := true
+		for i := 1; i < len(series) && growing; i++ {
+			prev, ok1 := series[i-1][tb.Ref.FullName]
+			cur, ok2 := series[i][tb.Ref.FullName]
+			growing = ok1 && ok2 && cur.Backlog > prev.Backlog
+		}
+		if growing {
+			first := series[0][tb.Ref.FullName].Backlog
+			out = append(out, finding("", tb.Backlog,
+				fmt.Sprintf("%s backlog growing for %d samples (%d -> %d)", tb.Ref.Name, r.samples, first, tb.Backlog)))
+		}
+	}
+	return out
+}
+
+func sortedSubscriptions(st *pulsarClient.TopicStats) []string {
+	names := make([]string, 0, len(st.Subscriptions))
+	for name := range st.Subscriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadCheckRules читает правила из JSON ({"rules": [...]} или [...]) или YAML.
+func loadCheckRules(path string) ([]*checkRule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		var rules []*checkRule
+		if err := json.Unmarshal(trimmed, &rules); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+		return rules, nil
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		var f checkRulesFile
+		if err := json.Unmarshal(trimmed, &f); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+		return f.Rules, nil
+	default:
+		rules, err := parseRulesYAML(b)
+		if err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+		return rules, nil
+	}
+}
+
+// parseRulesYAML — подмножество YAML, которого хватает для файла правил:
+// список плоских объектов (на верхнем уровне или под ключом rules:),
+// скаляры в кавычках или без, комментарии #.
+//
+//	rules:
+//	  - level: critical
+//	    rule: backlog > 10000
+//	    prefix: orders-
+func parseRulesYAML(b []byte) ([]*checkRule, error) {
+	var items []map[string]string
+	var cur map[string]string
+	itemIndent := -1
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		raw := stripYAMLComment(sc.Text())
+		if strings.TrimSpace(raw) == "" || strings.TrimSpace(raw) == "---" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		line := strings.TrimSpace(raw)
+
+		if line == "rules:" && indent == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "- ") || line == "-" {
+			if itemIndent >= 0 && indent != itemIndent {
+				return nil, fmt.Errorf("line %d: inconsistent list indentation", lineNo)
+			}
+			itemIndent = indent
+			cur = map[string]string{}
+			items = append(items, cur)
+			line = strings.TrimSpace(strings.TrimPrefix(line, "-"))
+			if line == "" {
+				continue
+			}
+		} else if cur == nil || indent <= itemIndent {
+			return nil, fmt.Errorf("line %d: expected a list item (- key: value)", lineNo)
+		}
+
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNo)
+		}
+		v, err := unquoteYAML(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		cur[strings.TrimSpace(key)] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*checkRule, 0, len(items))
+	for i, it := range items {
+		r := &checkRule{}
+		for k, v := range it {
+			switch k {
+			case "name":
+				r.Name = v
+			case "level":
+				r.Level = v
+			case "rule":
+				r.Rule = v
+			case "prefix":
+				r.Prefix = v
+			case "regex":
+				r.Regex = v
+			case "subscription":
+				r.Subscription = v
+			default:
+				return nil, fmt.Errorf("rule %d: unknown key %q", i+1, k)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func stripYAMLComment(s string) string {
+	inSingle, inDouble := false, false
+	for i, c := range s {
+		switch {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '#' && !inSingle && !inDouble && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func unquoteYAML(v string) (string, error) {
+	switch {
+	case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+		return strconv.Unquote(v)
+	case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	}
+	return v, nil
+}
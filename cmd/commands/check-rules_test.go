@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRuleCompile(t *testing.T) {
+	tests := []struct {
+		rule      string
+		level     string
+		wantErr   string
+		kind      string
+		threshold int64
+		inclusive bool
+		samples   int
+		wantLevel string
+	}{
+		{rule: "backlog > 100", kind: ruleBacklog, threshold: 100, wantLevel: levelWarning},
+		{rule: "backlog >= 100", kind: ruleBacklog, threshold: 100, inclusive: true, wantLevel: levelWarning},
+		{rule: "backlog>5", kind: ruleBacklog, threshold: 5, wantLevel: levelWarning},
+		{rule: "  Backlog   >   1_000_000 ", kind: ruleBacklog, threshold: 1000000, wantLevel: levelWarning},
+		{rule: "backlog > 10", level: "crit", kind: ruleBacklog, threshold: 10, wantLevel: levelCritical},
+		{rule: "no-consumers", level: "warn", kind: ruleNoConsumers, wantLevel: levelWarning},
+		{rule: "no consumers on subscriptions", kind: ruleNoConsumers, wantLevel: levelWarning},
+		{rule: "growing 3", kind: ruleGrowing, samples: 3, wantLevel: levelWarning},
+		{rule: "backlog growing for 2 samples", kind: ruleGrowing, samples: 2, wantLevel: levelWarning},
+
+		{rule: "backlog >> 5", wantErr: "cannot parse"},
+		{rule: "backlog > ", wantErr: "cannot parse"},
+		{rule: "backlog > -1", wantErr: "cannot parse"},
+		{rule: "backlog < 5", wantErr: "cannot parse"},
+		{rule: "backlog > 99999999999999999999", wantErr: "out of range"},
+		{rule: "growing 0", wantErr: "at least 1 sample"},
+		{rule: "growing", wantErr: "cannot parse"},
+		{rule: "backlog > 1", level: "fatal", wantErr: "unknown level"},
+		{rule: "", wantErr: "cannot parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r := &checkRule{Rule: tt.rule, Level: tt.level}
+			err := r.compile()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("compile(%q) error = %v, want %q", tt.rule, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compile(%q): %v", tt.rule, err)
+			}
+			if r.kind != tt.kind || r.threshold != tt.threshold || r.inclusive != tt.inclusive || r.samples != tt.samples {
+				t.Errorf("compile(%q) = kind %q threshold %d inclusive %v samples %d, want %q %d %v %d",
+					tt.rule, r.kind, r.threshold, r.inclusive, r.samples, tt.kind, tt.threshold, tt.inclusive, tt.samples)
+			}
+			if r.Level != tt.wantLevel {
+				t.Errorf("compile(%q) level = %q, want %q", tt.rule, r.Level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestCheckRuleCompileRegex(t *testing.T) {
+	for _, r := range []*checkRule{
+		{Rule: "backlog > 1", Regex: "orders-("},
+		{Rule: "no-consumers", Subscription: "[bad"},
+	} {
+		if err := r.compile(); err == nil {
+			t.Errorf("compile(%+v): expected regex error", r)
+		}
+	}
+}
+
+func TestParseRulesYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []checkRule
+		wantErr string
+	}{
+		{
+			name: "under rules key",
+			in: `rules:
+  - level: critical
+    rule: backlog > 10000
+    prefix: orders-
+  - rule: no-consumers
+    subscription: "^billing"
+`,
+			want: []checkRule{
+				{Level: "critical", Rule: "backlog > 10000", Prefix: "orders-"},
+				{Rule: "no-consumers", Subscription: "^billing"},
+			},
+		},
+		{
+			name: "top-level list, comments and document marker",
+			in: `---
+# global comment
+- name: dlq # trailing comment
+  rule: 'backlog > 0'
+  regex: "-dlq$"
+`,
+			want: []checkRule{{Name: "dlq", Rule: "backlog > 0", Regex: "-dlq$"}},
+		},
+		{
+			name: "hash inside quotes is not a comment",
+			in: `- rule: backlog > 1
+  regex: "a #b"
+  name: 'it''s # here' # real comment
+`,
+			want: []checkRule{{Rule: "backlog > 1", Regex: "a #b", Name: "it's # here"}},
+		},
+		{
+			name: "hash without leading space is part of the value",
+			in: `- rule: no-consumers
+  prefix: topic#1
+`,
+			want: []checkRule{{Rule: "no-consumers", Prefix: "topic#1"}},
+		},
+		{
+			name: "dash on its own line",
+			in: `-
+  rule: growing 3
+`,
+			want: []checkRule{{Rule: "growing 3"}},
+		},
+		{
+			name: "inconsistent list indentation",
+			in: `rules:
+  - rule: backlog > 1
+    - rule: backlog > 2
+`,
+			wantErr: "line 3: inconsistent list indentation",
+		},
+		{
+			name: "key outside of an item",
+			in: `rule: backlog > 1
+`,
+			wantErr: "line 1: expected a list item",
+		},
+		{
+			name: "key not indented under item",
+			in: `- rule: backlog > 1
+level: critical
+`,
+			wantErr: "line 2: expected a list item",
+		},
+		{
+			name: "missing colon",
+			in: `- rule backlog > 1
+`,
+			wantErr: "line 1: expected key: value",
+		},
+		{
+			name: "unknown key",
+			in: `- rule: backlog > 1
+  treshold: 5
+`,
+			wantErr: `rule 1: unknown key "treshold"`,
+		},
+		{
+			name: "bad double-quoted escape",
+			in: `- rule: "backlog \q"
+`,
+			wantErr: "line 1:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseRulesYAML([]byte(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != len(tt.want) {
+				t.Fatalf("got %d rules, want %d", len(rules), len(tt.want))
+			}
+			for i, r := range rules {
+				w := tt.want[i]
+				if r.Name != w.Name || r.Level != w.Level || r.Rule != w.Rule ||
+					r.Prefix != w.Prefix || r.Regex != w.Regex || r.Subscription != w.Subscription {
+					t.Errorf("rule %d = %+v, want %+v", i, *r, w)
+				}
+			}
+		})
+	}
+}
+
+func TestStripYAMLComment(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"key: value", "key: value"},
+		{"key: value # comment", "key: value "},
+		{"# whole line", ""},
+		{`key: "a # b"`, `key: "a # b"`},
+		{`key: 'a # b' # c`, `key: 'a # b' `},
+		{`key: "it's" # c`, `key: "it's" `},
+		{"key: a#b", "key: a#b"},
+	}
+	for _, tt := range tests {
+		if got := stripYAMLComment(tt.in); got != tt.want {
+			t.Errorf("stripYAMLComment(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUnquoteYAML(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "plain", want: "plain"},
+		{in: `"double \"q\""`, want: `double "q"`},
+		{in: `'single ''q'''`, want: `single 'q'`},
+		{in: `"`, want: `"`},
+		{in: `"a\q"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := unquoteYAML(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want && !tt.wantErr {
+			t.Errorf("unquoteYAML(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestFinishCheckReportCode(t *testing.T) {
+	warn := checkFinding{Level: levelWarning, Message: "w"}
+	crit := checkFinding{Level: levelCritical, Message: "c"}
+	tests := []struct {
+		name     string
+		findings []checkFinding
+		errors   []string
+		want     int
+	}{
+		{name: "nothing", want: checkOK},
+		{name: "warning", findings: []checkFinding{warn}, want: checkWarning},
+		{name: "critical", findings: []checkFinding{crit}, want: checkCritical},
+		{name: "critical after warning", findings: []checkFinding{warn, crit, warn}, want: checkCritical},
+		{name: "errors only", errors: []string{"stats x: 500"}, want: checkUnknown},
+		{name: "warning beats errors", findings: []checkFinding{warn}, errors: []string{"e"}, want: checkWarning},
+		{name: "critical beats errors", findings: []checkFinding{crit}, errors: []string{"e"}, want: checkCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &checkReport{Findings: tt.findings, Errors: tt.errors}
+			finishCheckReport(r)
+			if r.Code != tt.want {
+				t.Errorf("code = %d, want %d", r.Code, tt.want)
+			}
+			if r.Status != checkStatusNames[tt.want] || !strings.HasPrefix(r.Summary, r.Status+" - ") {
+				t.Errorf("status %q, summary %q", r.Status, r.Summary)
+			}
+		})
+	}
+}
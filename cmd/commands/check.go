@@ -0,0 +1,375 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+)
+
+// коды выхода check — как у плагинов Nagios
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3 // API недоступен / не удалось получить stats
+)
+
+var checkStatusNames = map[int]string{
+	checkOK:       "OK",
+	checkWarning:  "WARNING",
+	checkCritical: "CRITICAL",
+	checkUnknown:  "UNKNOWN",
+}
+
+// checkFinding — одно сработавшее правило.
+type checkFinding struct {
+	Level        string `json:"level"`
+	Rule         string `json:"rule"`
+	Topic        string `json:"topic"`
+	Subscription string `json:"subscription,omitempty"`
+	Value        int64  `json:"value"`
+	Message      string `json:"message"`
+	Context      string `json:"context,omitempty"` // при --context a,b,c / --all-contexts
+}
+
+type checkReport struct {
+	Status   string         `json:"status"`
+	Code     int            `json:"code"`
+	Summary  string         `json:"summary"`
+	Topics   int            `json:"topics"`
+	Backlog  int64          `json:"backlog"`
+	Samples  int            `json:"samples"`
+	Findings []checkFinding `json:"findings"`
+	Errors   []string       `json:"errors,omitempty"`
+}
+
+// checkSample — бэклоги и stats всех топиков в один момент времени.
+type checkSample map[string]pulsarClient.TopicBacklog
+
+// checkFlags — флаги check.
+type checkFlags struct {
+	g                                                    *globalFlags
+	warnRules, critRules                                 stringList
+	rulesFile, matchPrefix, matchRegex, subRegex, output string
+	sampleInterval                                       time.Duration
+	parallel                                             int
+	includeInternal, withPartitioned                     bool
+	ff                                                   *filterFlags
+	cf                                                   *contextFlags
+}
+
+func bindCheckFlags(fs *flag.FlagSet) *checkFlags {
+	o := &checkFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.Var(&o.warnRules, "warn", `warning rule, repeatable: "backlog > N", "no-consumers", "growing N"`)
+	fs.Var(&o.critRules, "crit", "critical rule, repeatable (same syntax as --warn)")
+	fs.StringVar(&o.rulesFile, "rules", "", "YAML or JSON file with rules")
+	fs.StringVar(&o.matchPrefix, "match-prefix", "", "apply flag rules to topics with this name prefix")
+	fs.StringVar(&o.matchRegex, "match-regex", "", "apply flag rules to topics matching this regex (short or full name)")
+	fs.StringVar(&o.subRegex, "subscription", "", "apply flag rules to subscriptions matching this regex")
+	fs.DurationVar(&o.sampleInterval, "sample-interval", 10*time.Second, "pause between samples for growing rules")
+	fs.IntVar(&o.parallel, "parallel", 16, "max parallel stats requests")
+	fs.BoolVar(&o.includeInternal, "include-internal", false, "include system/internal topics")
+	fs.BoolVar(&o.withPartitioned, "with-partitioned", true, "with partitioned topics")
+	fs.StringVar(&o.output, "output", "text", "output format: text (one Nagios line), json")
+	o.ff = bindFilterFlags(fs, false)
+	o.cf = bindContextFlags(fs, o.g)
+	return o
+}
+
+func CmdCheck(args []string) error {
+	fs := newFlagSet("check")
+	o := bindCheckFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		var ee *ExitError
+		if errors.As(err, &ee) && ee.Code == ExitUsage {
+			return checkUnknownExit("text", ee.Err)
+		}
+		return err
+	}
+	if o.output != "text" && o.output != outputJSON {
+		return checkUnknownExit("text", fmt.Errorf("unknown output format %q (supported: text, json)", o.output))
+	}
+
+	var rules []*checkRule
+	for _, r := range o.warnRules {
+		rules = append(rules, &checkRule{Level: levelWarning, Rule: r, Prefix: o.matchPrefix, Regex: o.matchRegex, Subscription: o.subRegex})
+	}
+	for _, r := range o.critRules {
+		rules = append(rules, &checkRule{Level: levelCritical, Rule: r, Prefix: o.matchPrefix, Regex: o.matchRegex, Subscription: o.subRegex})
+	}
+	if o.rulesFile != "" {
+		fileRules, err := loadCheckRules(o.rulesFile)
+		if err != nil {
+			return checkUnknownExit(o.output, err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	if len(rules) == 0 {
+		return checkUnknownExit(o.output, fmt.Errorf("no rules: use --warn, --crit or --rules"))
+	}
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			return checkUnknownExit(o.output, err)
+		}
+	}
+
+	ctxNames, err := o.cf.names()
+	if err != nil {
+		return checkUnknownExit(o.output, err)
+	}
+	var report *checkReport
+	if ctxNames == nil {
+		report = runCheck(o.g, o.ff, rules, o.sampleInterval, o.parallel, o.includeInternal, o.withPartitioned)
+	} else {
+		results := fanOut(o.cf, ctxNames, func(cg *globalFlags) (*checkReport, error) {
+			return runCheck(cg, o.ff, rules, o.sampleInterval, o.parallel, o.includeInternal, o.withPartitioned), nil
+		})
+		report = mergeCheckReports(results)
+	}
+	return printCheckReport(report, o.output)
+}
+
+// printCheckReport печатает отчёт и возвращает код выхода Nagios.
+func printCheckReport(report *checkReport, output string) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(report.Summary)
+	}
+	if report.Code == checkOK {
+		return nil
+	}
+	// сообщение уже напечатано в stdout — наружу только код
+	return &ExitError{Code: report.Code, Err: errSilent}
+}
+
+// checkUnknownExit — ошибка в правилах или флагах check. Это UNKNOWN (3),
+// а не usage (2): для Nagios 2 — CRITICAL, и опечатка в конфиге
+// поднимала бы людей как авария брокера.
+func checkUnknownExit(output string, err error) error {
+	report := &checkReport{Findings: []checkFinding{}, Errors: []string{err.Error()}}
+	finishCheckReport(report)
+	report.Summary = fmt.Sprintf("%s - %v", report.Status, err)
+	return printCheckReport(report, output)
+}
+
+func runCheck(
+	g *globalFlags,
+	ff *filterFlags,
+	rules []*checkRule,
+	sampleInterval time.Duration,
+	parallel int,
+	includeInternal bool,
+	withPartitioned bool,
+) *checkReport {
+	report := &checkReport{Findings: []checkFinding{}}
+	unknown := func(err error) *checkReport {
+		report.Errors = append(report.Errors, err.Error())
+		report.Code = checkUnknown
+		report.Status = checkStatusNames[checkUnknown]
+		report.Summary = fmt.Sprintf("%s - %v", report.Status, err)
+		return report
+	}
+
+	cx, err := g.loadContext()
+	if err != nil {
+		return unknown(err)
+	}
+	filter, err := ff.build(cx)
+	if err != nil {
+		return unknown(err)
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return unknown(err)
+	}
+	ctx := context.Background()
+
+	samples := 1
+	for _, r := range rules {
+		if r.kind == ruleGrowing {
+			samples = max(samples, r.samples+1)
+		}
+	}
+
+	var history []checkSample
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			if g.verbose {
+				fmt.Fprintf(os.Stderr, "[puls] check: sample %d/%d in %s\n", i+1, samples, sampleInterval)
+			}
+			time.Sleep(sampleInterval)
+		}
+		s, errs, err := fetchCheckSample(ctx, h, cx.Tenant, cx.Namespace, filter, includeInternal, withPartitioned, parallel)
+		if err != nil {
+			return unknown(err)
+		}
+		report.Errors = append(report.Errors, errs...)
+		history = append(history, s)
+	}
+	report.Samples = len(history)
+
+	last := history[len(history)-1]
+	names := make([]string, 0, len(last))
+	for name, tb := range last {
+		names = append(names, name)
+		report.Backlog += tb.Backlog
+	}
+	sort.Strings(names)
+	report.Topics = len(names)
+
+	for _, name := range names {
+		tb := last[name]
+		for _, r := range rules {
+			if !r.matchTopic(tb.Ref) {
+				continue
+			}
+			report.Findings = append(report.Findings, r.evaluate(tb, history)...)
+		}
+	}
+	finishCheckReport(report)
+	return report
+}
+
+// mergeCheckReports сводит отчёты нескольких контекстов в один: находки
+// и ошибки помечаются контекстом, недоступный контекст — это ошибка
+// (UNKNOWN, если больше ничего не сработало), остальные проверяются.
+func mergeCheckReports(results []contextResult[*checkReport]) *checkReport {
+	report := &checkReport{Findings: []checkFinding{}}
+	for _, res := range results {
+		r := res.Value
+		report.Topics += r.Topics
+		report.Backlog += r.Backlog
+		report.Samples = max(report.Samples, r.Samples)
+		for _, f := range r.Findings {
+			f.Context = res.Context
+			f.Message = "[" + res.Context + "] " + f.Message
+			report.Findings = append(report.Findings, f)
+		}
+		for _, e := range r.Errors {
+			report.Errors = append(report.Errors, "context "+res.Context+": "+e)
+		}
+	}
+	finishCheckReport(report)
+	return report
+}
+
+// finishCheckReport — код, статус и строка Nagios по находкам и ошибкам.
+func finishCheckReport(report *checkReport) {
+	report.Code = checkOK
+	for _, f := range report.Findings {
+		if f.Level == levelCritical {
+			report.Code = checkCritical
+			break
+		}
+		report.Code = checkWarning
+	}
+	if report.Code == checkOK && len(report.Errors) > 0 {
+		report.Code = checkUnknown
+	}
+	report.Status = checkStatusNames[report.Code]
+	report.Summary = checkSummary(report)
+}
+
+func fetchCheckSample(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	tenant, ns string,
+	filter *pulsarClient.TopicFilter,
+	includeInternal bool,
+	withPartitioned bool,
+	parallel int,
+) (checkSample, []string, error) {
+	s := checkSample{}
+	var errs []string
+	add := func(infos []pulsarClient.TopicBacklog) {
+		for _, info := range infos {
+			if info.Err != nil {
+				errs = append(errs, fmt.Sprintf("stats %s: %v", info.Ref.FullName, info.Err))
+				continue
+			}
+			s[info.Ref.FullName] = info
+		}
+	}
+
+	if filter.WantKind(pulsarClient.KindNonPartitioned) {
+		nonParts, err := pulsarClient.ListNonPartitionedTopics(ctx, h, tenant, ns, includeInternal)
+		if err != nil {
+			return nil, nil, err
+		}
+		nonParts = filter.FilterTopics(nonParts)
+		add(pulsarClient.FetchNonPartitionedBacklogsParallel(ctx, h, nonParts, parallel))
+	}
+
+	if withPartitioned && filter.WantKind(pulsarClient.KindPartitioned) {
+		parts, err := pulsarClient.ListPartitionedTopics(ctx, h, tenant, ns, includeInternal)
+		if err != nil {
+			return nil, nil, err
+		}
+		parts = filter.FilterTopics(parts)
+		add(pulsarClient.FetchPartitionedBacklogsParallel(ctx, h, parts, parallel))
+	}
+	return s, errs, nil
+}
+
+// checkSummary — строка в формате Nagios: статус, первые находки и perfdata.
+func checkSummary(r *checkReport) string {
+	var b strings.Builder
+	b.WriteString(r.Status)
+	b.WriteString(" - ")
+	switch {
+	case len(r.Findings) > 0:
+		crit, warn := 0, 0
+		for _, f := range r.Findings {
+			if f.Level == levelCritical {
+				crit++
+			} else {
+				warn++
+			}
+		}
+		fmt.Fprintf(&b, "%d critical, %d warning in %d topics: ", crit, warn, r.Topics)
+		// сначала critical, в строку — не больше трёх
+		sorted := append([]checkFinding(nil), r.Findings...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Level == levelCritical && sorted[j].Level != levelCritical
+		})
+		const maxShown = 3
+		for i, f := range sorted {
+			if i == maxShown {
+				fmt.Fprintf(&b, "; +%d more", len(sorted)-maxShown)
+				break
+			}
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			b.WriteString(f.Message)
+		}
+	case len(r.Errors) > 0:
+		fmt.Fprintf(&b, "%d errors fetching stats: %s", len(r.Errors), r.Errors[0])
+	default:
+		fmt.Fprintf(&b, "%d topics checked, total backlog %d", r.Topics, r.Backlog)
+	}
+	fmt.Fprintf(&b, " | topics=%d backlog=%d findings=%d errors=%d", r.Topics, r.Backlog, len(r.Findings), len(r.Errors))
+	return b.String()
+}
+
+// helpers
+
+func matchTopicName(re *regexp.Regexp, ref pulsarClient.TopicRef) bool {
+	return re.MatchString(ref.Name) || re.MatchString(ref.FullName)
+}
@@ -0,0 +1,446 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// коды выхода
+const (
+	ExitOK      = 0
+	ExitFailure = 1 // ошибка выполнения (сеть, API, конфиг)
+	ExitUsage   = 2 // неверные аргументы / неизвестная команда
+)
+
+// Command — узел дерева команд. У листа есть Run, у группы — Subcommands.
+type Command struct {
+	Name        string
+	Args        string // что идёт после имени в usage, например "--topic <name> [flags]"
+	Summary     string
+	Run         func(args []string) error
+	Flags       func(fs *flag.FlagSet) // объявляет флаги листа для справки и автодополнения; nil — только --config
+	Subcommands []*Command
+	Hidden      bool // не показывать в справке и автодополнении
+
+	parent *Command
+}
+
+func (c *Command) path() string {
+	if c.parent == nil || c.parent.parent == nil {
+		return c.Name
+	}
+	return c.parent.path() + " " + c.Name
+}
+
+// flagSet — FlagSet листа без запуска команды.
+func (c *Command) flagSet() *flag.FlagSet {
+	fs := newFlagSet(c.path())
+	if c.Flags != nil {
+		c.Flags(fs)
+	}
+	return fs
+}
+
+// flagsOf приводит bind-функцию команды к полю Command.Flags; Run
+// вызывает ту же функцию, так что флаги объявлены в одном месте.
+func flagsOf[T any](bind func(*flag.FlagSet) T) func(*flag.FlagSet) {
+	return func(fs *flag.FlagSet) { bind(fs) }
+}
+
+func (c *Command) find(name string) *Command {
+	for _, sc := range c.Subcommands {
+		if sc.Name == name {
+			return sc
+		}
+	}
+	return nil
+}
+
+func (c *Command) link() {
+	for _, sc := range c.Subcommands {
+		sc.parent = c
+		sc.link()
+	}
+}
+
+// ExitError несёт код выхода вместе с ошибкой.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// errSilent — команда уже напечатала результат сама (check),
+// нужен только код выхода.
+var errSilent = errors.New("")
+
+func usageErrorf(format string, a ...any) error {
+	return &ExitError{Code: ExitUsage, Err: fmt.Errorf(format, a...)}
+}
+
+// ExitCode переводит ошибку команды в код выхода процесса.
+func ExitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	return ExitFailure
+}
+
+var root *Command
+
+func init() {
+	root = &Command{
+		Name: "puls",
+		Subcommands: []*Command{
+			{
+				Name:    "context",
+				Summary: "manage contexts",
+				Subcommands: []*Command{
+					{Name: "current", Summary: "print the current context name", Run: contextCurrent},
+					{Name: "list", Summary: "list contexts (* marks current)", Run: contextList},
+					{Name: "use", Args: "<name>", Summary: "switch the current context", Run: contextUse},
+					{Name: "get", Args: "[name] [flags]", Summary: "print a context as JSON (secrets redacted)", Run: contextGet, Flags: flagsOf(bindContextGetFlags)},
+					{Name: "set", Args: "--name <name> [flags]", Summary: "create or update a context", Run: contextSet, Flags: flagsOf(bindContextSetFlags)},
+					{Name: "delete", Args: "<name>", Summary: "delete a context", Run: contextDelete},
+				},
+			},
+			{Name: "list", Args: "[flags]", Summary: "list topics with backlog", Run: CmdList, Flags: flagsOf(bindListFlags)},
+			{Name: "delete-empty-topics", Args: "[flags]", Summary: "delete unused topics (zero backlog, no clients)", Run: CmdDeleteEmptyTopics, Flags: flagsOf(bindDeleteEmptyTopicsFlags)},
+			{Name: "restore", Args: "--from <snapshot.json> [flags]", Summary: "recreate topics and subscriptions from a snapshot", Run: CmdRestore, Flags: flagsOf(bindRestoreFlags)},
+			{Name: "check", Args: "(--warn R | --crit R | --rules FILE) [flags]", Summary: "evaluate backlog/consumer rules, exit 0/1/2/3 (Nagios: ok/warning/critical/unknown)", Run: CmdCheck, Flags: flagsOf(bindCheckFlags)},
+			{Name: "top", Args: "[flags]", Summary: "live dashboard of backlog, backlog delta and rates", Run: CmdTop, Flags: flagsOf(bindTopFlags)},
+			{Name: "serve-metrics", Args: "[--listen :9888] [flags]", Summary: "serve Prometheus metrics for configured contexts", Run: CmdServeMetrics, Flags: flagsOf(bindServeMetricsFlags)},
+			{Name: "topic-info", Args: "--topic <name> [flags]", Summary: "show stats, producers and subscriptions of a topic", Run: CmdTopicInfo, Flags: flagsOf(bindTopicInfoFlags)},
+			{
+				Name:    "subscriptions",
+				Summary: "manage topic subscriptions",
+				Subcommands: []*Command{
+					{Name: "list", Args: "--topic <name> [flags]", Summary: "show subscriptions with backlog, consumers and unacked", Run: subscriptionsList, Flags: flagsOf(bindSubsListFlags)},
+					{Name: "clear-backlog", Args: "--topic <name> --sub <sub> [flags]", Summary: "skip all messages of a subscription", Run: subscriptionsClearBacklog, Flags: flagsOf(bindSubsClearBacklogFlags)},
+					{Name: "skip", Args: "--topic <name> --sub <sub> --count N [flags]", Summary: "skip N messages of a subscription", Run: subscriptionsSkip, Flags: flagsOf(bindSubsSkipFlags)},
+					{Name: "reset-cursor", Args: "--topic <name> --sub <sub> (--time T | --message-id L:E) [flags]", Summary: "move a subscription cursor", Run: subscriptionsResetCursor, Flags: flagsOf(bindSubsResetCursorFlags)},
+					{Name: "delete", Args: "--topic <name> --sub <sub> [flags]", Summary: "delete a subscription", Run: subscriptionsDelete, Flags: flagsOf(bindSubsDeleteFlags)},
+				},
+			},
+			{
+				Name:    "audit",
+				Summary: "audit log of mutating admin API calls",
+				Subcommands: []*Command{
+					{Name: "list", Args: "[--since 24h] [flags]", Summary: "show who changed what and when", Run: auditList, Flags: flagsOf(bindAuditListFlags)},
+				},
+			},
+			{
+				Name:    "tenants",
+				Summary: "tenants of the cluster",
+				Subcommands: []*Command{
+					{Name: "list", Args: "[flags]", Summary: "list tenants", Run: tenantsList, Flags: flagsOf(bindNameListFlags)},
+				},
+			},
+			{
+				Name:    "namespaces",
+				Summary: "namespaces of a tenant",
+				Subcommands: []*Command{
+					{Name: "list", Args: "[--tenant T] [flags]", Summary: "list namespaces of a tenant (default: context tenant)", Run: namespacesList, Flags: flagsOf(bindNameListFlags)},
+				},
+			},
+			{
+				Name:    "clusters",
+				Summary: "Pulsar clusters known to the broker",
+				Subcommands: []*Command{
+					{Name: "list", Args: "[flags]", Summary: "list clusters", Run: clustersList, Flags: flagsOf(bindNameListFlags)},
+				},
+			},
+			{Name: "completion", Args: "bash|zsh|fish", Summary: "print a shell completion script", Run: CmdCompletion},
+			{Name: "help", Args: "[command...]", Summary: "show help for a command", Run: cmdHelp},
+			{Name: "__complete", Run: cmdComplete, Hidden: true},
+		},
+	}
+	root.link()
+}
+
+// Main — точка входа: разбирает глобальные флаги до имени команды,
+// находит команду в дереве и возвращает код выхода.
+func Main(args []string) int {
+	err := run(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.Is(err, errSilent) {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	return ExitCode(err)
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet(root.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	// справку печатает printGroupHelp, а не стандартный "Usage of puls:"
+	fs.Usage = func() {}
+	addConfigFlag(fs)
+	bindGlobalFlags(fs, &globals)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printGroupHelp(os.Stdout, root)
+			return nil
+		}
+		return err
+	}
+	args = fs.Args()
+
+	cmd := root
+	for len(args) > 0 && cmd.Subcommands != nil {
+		sc := cmd.find(args[0])
+		if sc == nil {
+			if strings.HasPrefix(args[0], "-") && (args[0] == "-h" || args[0] == "--help" || args[0] == "-help") {
+				printGroupHelp(os.Stdout, cmd)
+				return nil
+			}
+			if cmd == root {
+				return usageErrorf("unknown command: %s (see: puls help)", args[0])
+			}
+			return usageErrorf("unknown subcommand: %s %s (see: puls help %s)", cmd.path(), args[0], cmd.path())
+		}
+		cmd = sc
+		args = args[1:]
+	}
+	if cmd.Run == nil {
+		printGroupHelp(os.Stderr, cmd)
+		if cmd == root {
+			return usageErrorf("command required")
+		}
+		return usageErrorf("%s: subcommand required", cmd.path())
+	}
+	return cmd.Run(args)
+}
+
+func cmdHelp(args []string) error {
+	fs := newFlagSet("help")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	cmd := root
+	for _, a := range args {
+		sc := cmd.find(a)
+		if sc == nil {
+			return usageErrorf("unknown command: %s", strings.Join(args, " "))
+		}
+		cmd = sc
+	}
+	if cmd.Run == nil {
+		printGroupHelp(os.Stdout, cmd)
+		return nil
+	}
+	if cmd.Name == "help" {
+		printCommandHeader(os.Stdout, cmd)
+		return nil
+	}
+	fs = cmd.flagSet()
+	fs.SetOutput(os.Stdout)
+	fs.Usage()
+	return nil
+}
+
+func printGroupHelp(w io.Writer, c *Command) {
+	if c == root {
+		fmt.Fprintln(w, "usage: puls [global flags] <command> [args]")
+	} else {
+		fmt.Fprintf(w, "usage: puls %s <subcommand> [args]\n", c.path())
+		if c.Summary != "" {
+			fmt.Fprintf(w, "\n%s\n", c.Summary)
+		}
+	}
+	fmt.Fprintln(w, "\ncommands:")
+	width := 0
+	for _, sc := range c.Subcommands {
+		if l := len(sc.Name); l > width && !sc.Hidden {
+			width = l
+		}
+	}
+	for _, sc := range c.Subcommands {
+		if sc.Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "  %-*s  %s\n", width, sc.Name, sc.Summary)
+	}
+	if c == root {
+		fmt.Fprintln(w, "\nglobal flags (before or after the command):")
+		fs := flag.NewFlagSet("puls", flag.ContinueOnError)
+		fs.SetOutput(w)
+		addConfigFlag(fs)
+		bindGlobalFlags(fs, &globalFlags{})
+		fs.PrintDefaults()
+		fmt.Fprintln(w, "\nexit codes: 0 ok, 1 error, 2 usage error")
+		fmt.Fprintln(w, "  check uses Nagios codes instead: 0 ok, 1 warning, 2 critical, 3 unknown")
+		fmt.Fprintln(w, "run 'puls help <command>' for command flags")
+	} else {
+		fmt.Fprintf(w, "\nrun 'puls help %s <subcommand>' for flags\n", c.path())
+	}
+}
+
+func printCommandHeader(w io.Writer, c *Command) {
+	fmt.Fprintf(w, "usage: puls %s %s\n", c.path(), c.Args)
+	if c.Summary != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Summary)
+	}
+}
+
+// lookupCommand ищет команду по пути вида "subscriptions list".
+func lookupCommand(path string) *Command {
+	cmd := root
+	for _, name := range strings.Fields(path) {
+		if cmd = cmd.find(name); cmd == nil {
+			return nil
+		}
+	}
+	return cmd
+}
+
+// newFlagSet создаёт FlagSet команды с общим --config и usage из реестра.
+func newFlagSet(path string) *flag.FlagSet {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		if c := lookupCommand(path); c != nil {
+			printCommandHeader(w, c)
+		} else {
+			fmt.Fprintf(w, "usage: puls %s [flags]\n", path)
+		}
+		fmt.Fprintln(w, "\nflags:")
+		fs.PrintDefaults()
+	}
+	addConfigFlag(fs)
+	return fs
+}
+
+// parseFlags — fs.Parse, где -h печатает справку в stdout, а ошибки
+// разбора становятся короткими ошибками usage (код 2) без простыни флагов.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	fs.SetOutput(os.Stdout)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, flag.ErrHelp):
+		fs.Usage()
+		return err
+	case fs.Name() == root.Name:
+		return usageErrorf("%v (see: puls help)", err)
+	default:
+		return usageErrorf("%v (see: puls help %s)", err, fs.Name())
+	}
+}
+
+func addConfigFlag(fs *flag.FlagSet) {
+	fs.Func("config", "path to config file (env "+pulsarConfig.EnvConfig+")", func(v string) error {
+		pulsarConfig.SetConfigPath(v)
+		return nil
+	})
+}
+
+// globalFlags — флаги подключения, общие для всех команд, которые ходят в API.
+type globalFlags struct {
+	ctxName    string
+	tenant     string
+	namespaces stringList // повторяемый только там, где включён multiNamespace
+	prefix     string
+	verbose    bool
+	retry      retryFlags
+
+	multiNamespace bool // команда умеет сканировать несколько namespace (bindScopeFlags)
+	multiContext   bool // команда умеет опрашивать несколько контекстов (bindContextFlags)
+}
+
+// globals — значения, переданные до имени команды; служат дефолтами
+// для тех же флагов после неё.
+var globals globalFlags
+
+func bindGlobalFlags(fs *flag.FlagSet, g *globalFlags) {
+	fs.StringVar(&g.ctxName, "context", g.ctxName, "context name (env "+pulsarConfig.EnvContext+", default: current)")
+	fs.StringVar(&g.tenant, "tenant", g.tenant, "override tenant (env "+pulsarConfig.EnvTenant+")")
+	fs.Var(&namespaceFlag{list: &g.namespaces}, "namespace", "override namespace, ns or tenant/ns; repeatable for multi-namespace commands (env "+pulsarConfig.EnvNamespace+")")
+	fs.StringVar(&g.prefix, "prefix", g.prefix, "topic name prefix filter (env "+pulsarConfig.EnvPrefix+")")
+	fs.BoolVar(&g.verbose, "verbose", g.verbose, "print detailed progress to stderr")
+	fs.IntVar(&g.retry.attempts, "retries", g.retry.attempts, "max attempts per HTTP request (0 = context/default)")
+	fs.DurationVar(&g.retry.backoff, "retry-backoff", g.retry.backoff, "initial retry backoff, e.g. 200ms")
+}
+
+// namespaceFlag — повторяемый --namespace. Значения, переданные до имени
+// команды, — только дефолт: первый --namespace после неё их заменяет,
+// а не дополняет (как у остальных глобальных флагов).
+type namespaceFlag struct {
+	list *stringList
+	set  bool
+}
+
+func (f *namespaceFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	return f.list.String()
+}
+
+func (f *namespaceFlag) Set(v string) error {
+	if !f.set {
+		*f.list, f.set = nil, true
+	}
+	return f.list.Set(v)
+}
+
+func addGlobalFlags(fs *flag.FlagSet) *globalFlags {
+	g := globals
+	g.namespaces = slices.Clone(globals.namespaces)
+	bindGlobalFlags(fs, &g)
+	return &g
+}
+
+// loadContext — выбранный контекст с применёнными флагами
+// (приоритет: флаг > env > контекст).
+func (g *globalFlags) loadContext() (*pulsarContext.Context, error) {
+	if strings.Contains(g.ctxName, ",") && !g.multiContext {
+		return nil, usageErrorf("--context a,b,c is supported only by list, topic-info and check")
+	}
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	cx, err := pulsarConfig.MustContext(cfg, g.ctxName)
+	if err != nil {
+		return nil, err
+	}
+	if g.tenant != "" {
+		cx.Tenant = g.tenant
+	}
+	switch {
+	case len(g.namespaces) > 1 && !g.multiNamespace:
+		return nil, usageErrorf("--namespace can be repeated only with list and delete-empty-topics")
+	case len(g.namespaces) == 1:
+		ref := parseNamespaceArg(g.namespaces[0], cx.Tenant)
+		cx.Tenant, cx.Namespace = ref.Tenant, ref.Namespace
+	}
+	if g.prefix != "" {
+		cx.Prefix = g.prefix
+	}
+	g.retry.apply(cx)
+	return cx, nil
+}
+
+// label — имя контекста для verbose-логов.
+func (g *globalFlags) label(cx *pulsarContext.Context) string {
+	if g.ctxName != "" {
+		return g.ctxName
+	}
+	return cx.Name
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNamespaceFlagOverridesGlobals(t *testing.T) {
+	tests := []struct {
+		name   string
+		global []string
+		args   []string
+		want   []string
+	}{
+		{name: "global only", global: []string{"--namespace", "a"}, want: []string{"a"}},
+		{name: "command replaces global", global: []string{"--namespace", "a"}, args: []string{"--namespace", "b"}, want: []string{"b"}},
+		{name: "repeated after command", global: []string{"--namespace", "a"}, args: []string{"--namespace", "b", "--namespace", "c"}, want: []string{"b", "c"}},
+		{name: "repeated before command", global: []string{"--namespace", "a", "--namespace", "b"}, want: []string{"a", "b"}},
+		{name: "none", want: nil},
+	}
+	saved := globals
+	defer func() { globals = saved }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globals = globalFlags{}
+			gfs := flag.NewFlagSet("puls", flag.ContinueOnError)
+			gfs.SetOutput(io.Discard)
+			bindGlobalFlags(gfs, &globals)
+			if err := gfs.Parse(tt.global); err != nil {
+				t.Fatal(err)
+			}
+			before := slices.Clone(globals.namespaces)
+			fs := flag.NewFlagSet("cmd", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			g := addGlobalFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(g.namespaces, tt.want) {
+				t.Errorf("namespaces = %v, want %v", g.namespaces, tt.want)
+			}
+			if !slices.Equal(globals.namespaces, before) {
+				t.Errorf("globals changed: %v -> %v", before, globals.namespaces)
+			}
+		})
+	}
+}
+
+func TestRunRootFlagErrorHint(t *testing.T) {
+	saved := globals
+	defer func() { globals = saved }()
+	err := run([]string{"--bogus"})
+	if ExitCode(err) != ExitUsage {
+		t.Fatalf("exit code = %d, want %d", ExitCode(err), ExitUsage)
+	}
+	if got := err.Error(); !strings.HasSuffix(got, "(see: puls help)") {
+		t.Errorf("error = %q, want hint \"(see: puls help)\"", got)
+	}
+}
+
+func TestCommandFlagSetFromRegistry(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "list", want: []string{"config", "context", "min-backlog", "all-contexts", "template"}},
+		{path: "subscriptions skip", want: []string{"topic", "sub", "count", "dry-run", "yes"}},
+		{path: "subscriptions list", want: []string{"topic"}},
+		{path: "context set", want: []string{"name", "token-file", "interactive"}},
+		{path: "namespaces list", want: []string{"tenant", "output"}},
+		{path: "context use", want: []string{"config"}},
+	}
+	for _, tt := range tests {
+		c := lookupCommand(tt.path)
+		if c == nil {
+			t.Fatalf("command %q not found", tt.path)
+		}
+		fs := c.flagSet()
+		for _, name := range tt.want {
+			if fs.Lookup(name) == nil {
+				t.Errorf("%s: flag --%s missing", tt.path, name)
+			}
+		}
+	}
+	if fs := lookupCommand("subscriptions list").flagSet(); fs.Lookup("yes") != nil {
+		t.Errorf("subscriptions list: unexpected --yes")
+	}
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// retryFlags — переопределение retry-настроек контекста из командной строки
+// (--retries, --retry-backoff; регистрируются в bindGlobalFlags).
+type retryFlags struct {
+	attempts int
+	backoff  time.Duration
+}
+
+func (r *retryFlags) apply(cx *pulsarContext.Context) {
+	if r.attempts > 0 {
+		cx.RetryMaxAttempts = r.attempts
+	}
+	if r.backoff > 0 {
+		cx.RetryBackoffMs = int(r.backoff / time.Millisecond)
+	}
+}
+
+// isFlagSet — был ли флаг явно передан (нужно для bool, где false тоже значение).
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// stringList — повторяемый строковый флаг.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func nonEmpty(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// filterFlags — --include/--exclude/--kind/--min-backlog/--max-backlog
+// для команд, которые обходят много топиков.
+type filterFlags struct {
+	include    stringList
+	exclude    stringList
+	full       bool
+	kind       string
+	minBacklog *int64
+	maxBacklog *int64
+}
+
+func bindFilterFlags(fs *flag.FlagSet, withBacklog bool) *filterFlags {
+	f := &filterFlags{}
+	fs.Var(&f.include, "include", "topic pattern to include, repeatable: prefix:P, glob:G, regex:R (bare value: glob if it has *?[, else prefix)")
+	fs.Var(&f.exclude, "exclude", "topic pattern to exclude, repeatable (same syntax as --include)")
+	fs.BoolVar(&f.full, "match-full-name", false, "match patterns against the full name (persistent://tenant/ns/name) instead of the short name")
+	fs.StringVar(&f.kind, "kind", "all", "topic kind: all, non-partitioned, partitioned")
+	if withBacklog {
+		fs.Func("min-backlog", "only topics with backlog >= N", int64Setter(&f.minBacklog))
+		fs.Func("max-backlog", "only topics with backlog <= N", int64Setter(&f.maxBacklog))
+	}
+	return f
+}
+
+// build — фильтр из флагов плюс prefix/include/exclude контекста.
+func (f *filterFlags) build(cx *pulsarContext.Context) (*pulsarClient.TopicFilter, error) {
+	include := append(append([]string(nil), cx.Include...), f.include...)
+	exclude := append(append([]string(nil), cx.Exclude...), f.exclude...)
+	tf, err := pulsarClient.NewTopicFilter(cx.Prefix, include, exclude, f.full)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+	switch f.kind {
+	case "", "all":
+	case pulsarClient.KindNonPartitioned, pulsarClient.KindPartitioned:
+		tf.Kinds = []string{f.kind}
+	default:
+		return nil, usageErrorf("unknown --kind %q (supported: all, %s, %s)", f.kind, pulsarClient.KindNonPartitioned, pulsarClient.KindPartitioned)
+	}
+	if f.minBacklog != nil && *f.minBacklog < 0 {
+		return nil, usageErrorf("--min-backlog must be >= 0")
+	}
+	if f.maxBacklog != nil && *f.maxBacklog < 0 {
+		return nil, usageErrorf("--max-backlog must be >= 0")
+	}
+	if f.minBacklog != nil && f.maxBacklog != nil && *f.minBacklog > *f.maxBacklog {
+		return nil, usageErrorf("--min-backlog %d is greater than --max-backlog %d", *f.minBacklog, *f.maxBacklog)
+	}
+	tf.MinBacklog, tf.MaxBacklog = f.minBacklog, f.maxBacklog
+	return tf, nil
+}
+
+// kindRequested — вид явно выбран через --kind (тогда он включается,
+// даже если по умолчанию команда его не смотрит).
+func (f *filterFlags) kindRequested(kind string) bool {
+	return f.kind == kind
+}
+
+func int64Setter(dst **int64) func(string) error {
+	return func(v string) error {
+		n, err := strconv.ParseInt(strings.ReplaceAll(v, "_", ""), 10, 64)
+		if err != nil {
+			return err
+		}
+		*dst = &n
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	pulsarContext "puls/cmd/ctx"
+)
+
+func TestFilterFlagsBuildBacklogRange(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: nil},
+		{args: []string{"--min-backlog", "0", "--max-backlog", "0"}},
+		{args: []string{"--min-backlog", "1_000", "--max-backlog", "2000"}},
+		{args: []string{"--min-backlog", "-1"}, wantErr: "--min-backlog must be >= 0"},
+		{args: []string{"--max-backlog", "-5"}, wantErr: "--max-backlog must be >= 0"},
+		{args: []string{"--min-backlog", "10", "--max-backlog", "5"}, wantErr: "greater than --max-backlog"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			fs := newFlagSet("test")
+			f := bindFilterFlags(fs, true)
+			if err := parseFlags(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			_, err := f.build(&pulsarContext.Context{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || ExitCode(err) != ExitUsage {
+				t.Errorf("build error = %v, want usage error %q", err, tt.wantErr)
+			}
+		})
+	}
+}
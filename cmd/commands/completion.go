@@ -0,0 +1,413 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+const (
+	completionTimeout  = 2 * time.Second
+	completionCacheTTL = time.Minute
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// партиции partitioned-топиков в автодополнении не нужны
+var partitionSuffix = regexp.MustCompile(`-partition-\d+$`)
+
+const bashCompletion = `# bash completion for puls; load with: source <(puls completion bash)
+_puls_complete() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null 2>&1; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+    local IFS=$'\n'
+    COMPREPLY=($(puls __complete "${words[@]:1:cword}" 2>/dev/null))
+    if declare -F __ltrim_colon_completions >/dev/null 2>&1; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _puls_complete puls
+`
+
+const zshCompletion = `#compdef puls
+# zsh completion for puls; load with: source <(puls completion zsh)
+_puls() {
+    local -a candidates
+    candidates=("${(@f)$(puls __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -Q -- "${(@)candidates}"
+}
+if [ "$funcstack[1]" = "_puls" ]; then
+    _puls "$@"
+else
+    compdef _puls puls
+fi
+`
+
+const fishCompletion = `# fish completion for puls; load with: puls completion fish | source
+function __puls_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    puls __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c puls -f -a '(__puls_complete)'
+`
+
+func CmdCompletion(args []string) error {
+	fs := newFlagSet("completion")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("usage: puls completion bash|zsh|fish")
+	}
+	switch fs.Arg(0) {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return usageErrorf("unsupported shell %q (supported: %s)", fs.Arg(0), strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+// cmdComplete — скрытая команда, которую вызывают скрипты автодополнения:
+// аргументы — слова после "puls", последнее — дописываемое (может быть пустым).
+func cmdComplete(args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for _, c := range completeWords(args[:len(args)-1], args[len(args)-1]) {
+		fmt.Println(c)
+	}
+	return nil
+}
+
+func completeWords(prev []string, cur string) []string {
+	cmd := root
+	fs := rootFlagSet()
+	values := map[string]string{}
+	var positional []string
+
+	// проходим уже введённые слова: подкоманды, флаги и их значения
+	for i := 0; i < len(prev); i++ {
+		tok := prev[i]
+		if strings.HasPrefix(tok, "-") && tok != "-" {
+			name, val, hasVal := strings.Cut(strings.TrimLeft(tok, "-"), "=")
+			if !hasVal && !isBoolFlag(fs, name) {
+				if i+1 >= len(prev) {
+					// значение флага — это и есть дописываемое слово
+					return filterPrefix(completeFlagValue(cmd, name, values), cur)
+				}
+				i++
+				val = prev[i]
+			}
+			values[name] = val
+			continue
+		}
+		if cmd.Subcommands != nil {
+			if sc := cmd.find(tok); sc != nil && !sc.Hidden {
+				cmd = sc
+				if cmd.Run != nil {
+					fs = cmd.flagSet()
+				}
+				continue
+			}
+		}
+		positional = append(positional, tok)
+	}
+
+	// --flag=value
+	if strings.HasPrefix(cur, "-") && strings.Contains(cur, "=") {
+		name, val, _ := strings.Cut(strings.TrimLeft(cur, "-"), "=")
+		dashes := cur[:len(cur)-len(strings.TrimLeft(cur, "-"))]
+		var out []string
+		for _, v := range filterPrefix(completeFlagValue(cmd, name, values), val) {
+			out = append(out, dashes+name+"="+v)
+		}
+		return out
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		var out []string
+		fs.VisitAll(func(f *flag.Flag) {
+			out = append(out, "--"+f.Name)
+		})
+		return filterPrefix(out, cur)
+	}
+
+	if cmd.Subcommands != nil {
+		var out []string
+		for _, sc := range cmd.Subcommands {
+			if !sc.Hidden {
+				out = append(out, sc.Name)
+			}
+		}
+		return filterPrefix(out, cur)
+	}
+
+	// позиционные аргументы
+	switch cmd.path() {
+	case "context use", "context get", "context delete":
+		if len(positional) == 0 {
+			return filterPrefix(contextNames(), cur)
+		}
+	case "completion":
+		if len(positional) == 0 {
+			return filterPrefix(completionShells, cur)
+		}
+	case "help":
+		h := root
+		for _, p := range positional {
+			if h = h.find(p); h == nil {
+				return nil
+			}
+		}
+		var out []string
+		for _, sc := range h.Subcommands {
+			if !sc.Hidden {
+				out = append(out, sc.Name)
+			}
+		}
+		return filterPrefix(out, cur)
+	}
+	return nil
+}
+
+func completeFlagValue(cmd *Command, name string, values map[string]string) []string {
+	switch name {
+	case "context":
+		return contextNames()
+	case "output":
+		return outputFormats
+	case "auth-type":
+		return pulsarClient.AuthTypes
+	case "kind":
+		return []string{"all", pulsarClient.KindNonPartitioned, pulsarClient.KindPartitioned}
+	case "tenant":
+		if cmd.path() == "context set" {
+			return nil
+		}
+		return remoteCompletions(values, "tenants")
+	case "namespace":
+		if cmd.path() == "context set" {
+			return nil
+		}
+		return remoteCompletions(values, "namespaces")
+	case "topic":
+		return remoteCompletions(values, "topics")
+	case "sub":
+		return remoteCompletions(values, "subscriptions")
+	}
+	return nil
+}
+
+// remoteCompletions — имена из admin API текущего контекста,
+// с коротким таймаутом и кэшем на диске.
+func remoteCompletions(values map[string]string, kind string) []string {
+	g := globalFlags{
+		ctxName: values["context"],
+		tenant:  values["tenant"],
+	}
+	if ns := values["namespace"]; ns != "" {
+		g.namespaces = stringList{ns}
+	}
+	cx, err := completionContext(&g)
+	if err != nil {
+		return nil
+	}
+
+	key := strings.Join([]string{cx.AdminURL, kind, cx.Tenant, cx.Namespace, values["topic"]}, "\x00")
+	if cached, ok := readCompletionCache(key); ok {
+		return cached
+	}
+
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	var out []string
+	switch kind {
+	case "tenants":
+		out, err = pulsarClient.ListTenants(ctx, h)
+	case "namespaces":
+		var full []string
+		full, err = pulsarClient.ListNamespaces(ctx, h, cx.Tenant)
+		for _, n := range full {
+			out = append(out, strings.TrimPrefix(n, cx.Tenant+"/"))
+		}
+	case "topics":
+		out, err = completionTopics(ctx, h, cx)
+	case "subscriptions":
+		if values["topic"] == "" {
+			return nil
+		}
+		var ref pulsarClient.TopicRef
+		ref, err = pulsarClient.ParseTopicArg(values["topic"], cx)
+		if err != nil {
+			return nil
+		}
+		var subs []pulsarClient.SubscriptionInfo
+		subs, err = pulsarClient.ListSubscriptions(ctx, h, ref)
+		for _, s := range subs {
+			out = append(out, s.Name)
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	writeCompletionCache(key, out)
+	return out
+}
+
+// completionTopics — короткие имена и полные persistent://... имена топиков,
+// для non-persistent — только полные (короткое имя значит persistent).
+func completionTopics(ctx context.Context, h *pulsarClient.HttpClient, cx *pulsarContext.Context) ([]string, error) {
+	nonParts, err := pulsarClient.ListNonPartitionedTopics(ctx, h, cx.Tenant, cx.Namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := pulsarClient.ListPartitionedTopics(ctx, h, cx.Tenant, cx.Namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	topics := append(nonParts, parts...)
+	// non-persistent — по возможности: без них дополнение всё равно полезно
+	for _, partitioned := range []bool{false, true} {
+		np, err := pulsarClient.ListTopics(ctx, h, pulsarClient.DomainNonPersistent, cx.Tenant, cx.Namespace, partitioned, false)
+		if err == nil {
+			topics = append(topics, np...)
+		}
+	}
+	var out []string
+	for _, t := range topics {
+		if partitionSuffix.MatchString(t.Name) {
+			continue
+		}
+		if t.IsPersistent() {
+			out = append(out, t.Name)
+		}
+		out = append(out, t.FullName)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func completionContext(g *globalFlags) (*pulsarContext.Context, error) {
+	cx, err := g.loadContext()
+	if err != nil {
+		return nil, err
+	}
+	// автодополнение не должно висеть на ретраях
+	cx.RetryMaxAttempts = 1
+	if cx.HTTPTimeoutSec > int(completionTimeout/time.Second) {
+		cx.HTTPTimeoutSec = int(completionTimeout / time.Second)
+	}
+	return cx, nil
+}
+
+func contextNames() []string {
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// helpers
+
+func rootFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("puls", flag.ContinueOnError)
+	addConfigFlag(fs)
+	bindGlobalFlags(fs, &globalFlags{})
+	return fs
+}
+
+func isBoolFlag(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+func filterPrefix(items []string, prefix string) []string {
+	var out []string
+	for _, it := range items {
+		if strings.HasPrefix(it, prefix) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+type completionCacheEntry struct {
+	Time  time.Time `json:"time"`
+	Items []string  `json:"items"`
+}
+
+func completionCachePath(key string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(dir, "puls", "completion", hex.EncodeToString(sum[:])+".json"), nil
+}
+
+func readCompletionCache(key string) ([]string, bool) {
+	p, err := completionCachePath(key)
+	if err != nil {
+		return nil, false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	var e completionCacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || time.Since(e.Time) > completionCacheTTL {
+		return nil, false
+	}
+	return e.Items, true
+}
+
+func writeCompletionCache(key string, items []string) {
+	p, err := completionCachePath(key)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return
+	}
+	b, _ := json.Marshal(completionCacheEntry{Time: time.Now(), Items: items})
+	os.WriteFile(p, b, 0o600)
+}
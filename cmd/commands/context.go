@@ -1,159 +1,485 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
 	"sort"
-	"flag"
+	"os"
 	"strings"
 	"errors"
+	"flag"
 	"encoding/json"
+	"slices"
+	pulsarClient "puls/cmd/client"
 	pulsarConfig "puls/cmd/config"
 	pulsarContext "puls/cmd/ctx"
 )
 
-func CmdContext(args []string) error {
-	if len(args) == 0 {
-		return errors.New("usage: puls context [use|current|get|set|list|delete]")
+func contextCurrent(args []string) error {
+	fs := newFlagSet("context current")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if name := os.Getenv(pulsarConfig.EnvContext); name != "" {
+		fmt.Printf("%s (from %s)\n", name, pulsarConfig.EnvContext)
+		return nil
 	}
-	sub := args[0]
 	cfg, err := pulsarConfig.LoadConfig()
 	if err != nil {
 		return err
 	}
+	if cfg.Current == "" {
+		fmt.Println("(no current context)")
+		return nil
+	}
+	fmt.Println(cfg.Current)
+	return nil
+}
 
-	switch sub {
-
-	case "current":
-		if cfg.Current == "" {
-			fmt.Println("(no current context)")
-			return nil
-		}
-		fmt.Println(cfg.Current)
+func contextList(args []string) error {
+	fs := newFlagSet("context list")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if len(cfg.Contexts) == 0 {
+		fmt.Println("(no contexts)")
 		return nil
+	}
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		mark := " "
+		if name == cfg.Current {
+			mark = "*"
+		}
+		if cfg.Contexts[name].ReadOnly {
+			fmt.Printf("%s %s (readonly)\n", mark, name)
+			continue
+		}
+		fmt.Printf("%s %s\n", mark, name)
+	}
+	return nil
+}
+
+func contextUse(args []string) error {
+	fs := newFlagSet("context use")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("usage: puls context use <name>")
+	}
+	name := fs.Arg(0)
+
+	unlock, err := pulsarConfig.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found", name)
+	}
+	cfg.Current = name
+	if err := pulsarConfig.SaveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Println("current context:", name)
+	return nil
+}
+
+// contextGetFlags — флаги context get.
+type contextGetFlags struct {
+	showSecrets bool
+}
+
+func bindContextGetFlags(fs *flag.FlagSet) *contextGetFlags {
+	o := &contextGetFlags{}
+	fs.BoolVar(&o.showSecrets, "show-secrets", false, "print tokens and passwords instead of redacting them")
+	return o
+}
+
+func contextGet(args []string) error {
+	fs := newFlagSet("context get")
+	o := bindContextGetFlags(fs)
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = cfg.Current
+	}
+	if name == "" {
+		return errors.New("no context selected; use: puls context use <name>")
+	}
+	c := cfg.Contexts[name]
+	if c == nil {
+		return fmt.Errorf("context %q not found", name)
+	}
+	out := *c
+	if !o.showSecrets {
+		pulsarConfig.RedactSecrets(&out)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(&out)
+}
+
+func contextDelete(args []string) error {
+	fs := newFlagSet("context delete")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("usage: puls context delete <name>")
+	}
+	name := fs.Arg(0)
+
+	unlock, err := pulsarConfig.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Contexts[name]; !ok {
+		return fmt.Errorf("context %q not found", name)
+	}
+	delete(cfg.Contexts, name)
+	if cfg.Current == name {
+		cfg.Current = ""
+	}
+	if err := pulsarConfig.SaveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Println("deleted context:", name)
+	return nil
+}
+
+// contextSetFlags — флаги context set.
+type contextSetFlags struct {
+	name, urlStr, tok, tenant, ns, prefix                                                   string
+	timeout                                                                                 int
+	retries, retryBackoffMs, retryMaxBackoffMs                                              int
+	retryNonIdempotent                                                                      bool
+	tlsCA, tlsCert, tlsKey, tlsServerName                                                   string
+	tlsInsecure                                                                             bool
+	authType, tokenFile, tokenExec                                                          string
+	tokenExecTTL                                                                            int
+	oauthIssuer, oauthClientID, oauthClientSecret, oauthCredFile, oauthAudience, oauthScope string
+	basicUser, basicPassword                                                                string
+	include, exclude, protected                                                             stringList
+	readOnly                                                                                bool
+	auditLog                                                                                string
+	interactive                                                                             bool
+}
 
-	case "list":
-		if len(cfg.Contexts) == 0 {
-			fmt.Println("(no contexts)")
-			return nil
+func bindContextSetFlags(fs *flag.FlagSet) *contextSetFlags {
+	o := &contextSetFlags{}
+	fs.StringVar(&o.name, "name", "", "context name (required)")
+	fs.StringVar(&o.urlStr, "url", "", "admin URL (e.g. http://broker:8080/admin/v2)")
+	fs.StringVar(&o.tok, "token", "", "bearer token or secret reference env:NAME, file:/path, exec:cmd; replaces --token-file/--token-exec (\"\" clears)")
+	fs.StringVar(&o.tenant, "tenant", "", "tenant (e.g. amocrm)")
+	fs.StringVar(&o.ns, "namespace", "", "namespace (e.g. core-dev)")
+	fs.StringVar(&o.prefix, "prefix", "", "topic name prefix filter (optional)")
+	fs.Var(&o.include, "include", "topic pattern to include, repeatable; replaces the saved list (\"\" clears)")
+	fs.Var(&o.exclude, "exclude", "topic pattern to exclude, repeatable; replaces the saved list (\"\" clears)")
+	fs.Var(&o.protected, "protected", "topic pattern destructive commands never touch, repeatable; replaces the saved list (\"\" clears)")
+	fs.BoolVar(&o.readOnly, "readonly", false, "refuse destructive commands in this context")
+	fs.StringVar(&o.auditLog, "audit-log", "", "audit log file for mutating requests (default: audit.jsonl next to the config)")
+	fs.IntVar(&o.timeout, "timeout", 10, "HTTP timeout in seconds")
+	fs.IntVar(&o.retries, "retries", 0, "max attempts per HTTP request (default 3)")
+	fs.IntVar(&o.retryBackoffMs, "retry-backoff-ms", 0, "initial retry backoff in ms (default 200)")
+	fs.IntVar(&o.retryMaxBackoffMs, "retry-max-backoff-ms", 0, "max retry backoff in ms (default 5000)")
+	fs.BoolVar(&o.retryNonIdempotent, "retry-non-idempotent", false, "also retry POST requests")
+	fs.StringVar(&o.tlsCA, "tls-ca-file", "", "PEM file with CA certificates for the admin endpoint")
+	fs.StringVar(&o.tlsCert, "tls-cert-file", "", "client certificate PEM file (mTLS)")
+	fs.StringVar(&o.tlsKey, "tls-key-file", "", "client private key PEM file (mTLS)")
+	fs.BoolVar(&o.tlsInsecure, "tls-insecure-skip-verify", false, "skip server certificate verification (testing only)")
+	fs.StringVar(&o.tlsServerName, "tls-server-name", "", "server name for SNI and certificate verification")
+	fs.StringVar(&o.authType, "auth-type", "", "auth provider: "+strings.Join(pulsarClient.AuthTypes, ", ")+" (default: inferred)")
+	fs.StringVar(&o.tokenFile, "token-file", "", "file with bearer token, re-read on every request; replaces --token/--token-exec (\"\" clears)")
+	fs.StringVar(&o.tokenExec, "token-exec", "", "command that prints a bearer token to stdout; replaces --token/--token-file (\"\" clears)")
+	fs.IntVar(&o.tokenExecTTL, "token-exec-ttl", 0, "seconds to cache the --token-exec result (default 300)")
+	fs.StringVar(&o.oauthIssuer, "oauth2-issuer-url", "", "OAuth2 issuer URL (client credentials flow)")
+	fs.StringVar(&o.oauthClientID, "oauth2-client-id", "", "OAuth2 client id")
+	fs.StringVar(&o.oauthClientSecret, "oauth2-client-secret", "", "OAuth2 client secret or secret reference")
+	fs.StringVar(&o.oauthCredFile, "oauth2-credentials-file", "", "Pulsar OAuth2 key file (client_id, client_secret, issuer_url)")
+	fs.StringVar(&o.oauthAudience, "oauth2-audience", "", "OAuth2 audience (optional)")
+	fs.StringVar(&o.oauthScope, "oauth2-scope", "", "OAuth2 scope (optional)")
+	fs.StringVar(&o.basicUser, "basic-user", "", "basic auth user")
+	fs.StringVar(&o.basicPassword, "basic-password", "", "basic auth password or secret reference")
+	fs.BoolVar(&o.interactive, "interactive", false, "ask for the admin URL, then pick tenant and namespace from the cluster")
+	return o
+}
+
+func contextSet(args []string) error {
+	fs := newFlagSet("context set")
+	o := bindContextSetFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	rest := fs.Args()
+	if o.urlStr == "" && len(rest) > 0 {
+		o.urlStr = rest[0]
+	}
+	if o.name == "" {
+		return usageErrorf("--name is required")
+	}
+	if n := countNonEmpty(o.tok, o.tokenFile, o.tokenExec); n > 1 {
+		return usageErrorf("--token, --token-file and --token-exec are mutually exclusive")
+	}
+
+	// при --interactive флаги применяются дважды: к копии контекста для
+	// запросов к API и к сохраняемому контексту
+	apply := func(cx *pulsarContext.Context) error {
+		if o.urlStr != "" {
+			cx.AdminURL = strings.TrimRight(o.urlStr, "/")
+		}
+		if o.tenant != "" {
+			cx.Tenant = o.tenant
+		}
+		if o.ns != "" {
+			cx.Namespace = o.ns
 		}
-		names := make([]string, 0, len(cfg.Contexts))
-		for name := range cfg.Contexts {
-			names = append(names, name)
+		if o.prefix != "" {
+			cx.Prefix = o.prefix
 		}
-		sort.Strings(names)
-		for _, name := range names {
-			mark := " "
-			if name == cfg.Current {
-				mark = "*"
+		if isFlagSet(fs, "include") {
+			cx.Include = nonEmpty(o.include)
+		}
+		if isFlagSet(fs, "exclude") {
+			cx.Exclude = nonEmpty(o.exclude)
+		}
+		if _, err := pulsarClient.NewTopicFilter("", cx.Include, cx.Exclude, false); err != nil {
+			return usageErrorf("%v", err)
+		}
+		if isFlagSet(fs, "protected") {
+			cx.Protected = nonEmpty(o.protected)
+			if _, err := newProtectedTopics(cx); err != nil {
+				return usageErrorf("%v", err)
 			}
-			fmt.Printf("%s %s\n", mark, name)
 		}
-		return nil
-
-	case "use":
-		if len(args) < 2 {
-			return errors.New("usage: puls context use <name>")
+		if isFlagSet(fs, "readonly") {
+			cx.ReadOnly = o.readOnly
 		}
-		name := args[1]
-		if _, ok := cfg.Contexts[name]; !ok {
-			return fmt.Errorf("context %q not found", name)
+		if isFlagSet(fs, "audit-log") {
+			cx.AuditLog = o.auditLog
 		}
-		cfg.Current = name
-		if err := pulsarConfig.SaveConfig(cfg); err != nil {
-			return err
+		if o.timeout > 0 {
+			cx.HTTPTimeoutSec = o.timeout
 		}
-		fmt.Println("current context:", name)
-		return nil
-
-	case "get":
-		var name string
-		if len(args) >= 2 {
-			name = args[1]
-		} else {
-			name = cfg.Current
+		if o.retries > 0 {
+			cx.RetryMaxAttempts = o.retries
 		}
-		if name == "" {
-			return errors.New("no context selected; use: puls context use <name>")
+		if o.retryBackoffMs > 0 {
+			cx.RetryBackoffMs = o.retryBackoffMs
 		}
-		c := cfg.Contexts[name]
-		if c == nil {
-			return fmt.Errorf("context %q not found", name)
+		if o.retryMaxBackoffMs > 0 {
+			cx.RetryMaxBackoffMs = o.retryMaxBackoffMs
 		}
-		b, _ := json.MarshalIndent(c, "", "  ")
-		fmt.Println(string(b))
-		return nil
-
-	case "delete":
-		if len(args) < 2 {
-			return errors.New("usage: puls context delete <name>")
+		if isFlagSet(fs, "retry-non-idempotent") {
+			cx.RetryNonIdempotent = o.retryNonIdempotent
 		}
-		name := args[1]
-		if _, ok := cfg.Contexts[name]; !ok {
-			return fmt.Errorf("context %q not found", name)
+		if o.tlsCA != "" {
+			cx.TLSCAFile = o.tlsCA
 		}
-		delete(cfg.Contexts, name)
-		if cfg.Current == name {
-			cfg.Current = ""
+		if o.tlsCert != "" {
+			cx.TLSCertFile = o.tlsCert
 		}
-		if err := pulsarConfig.SaveConfig(cfg); err != nil {
-			return err
+		if o.tlsKey != "" {
+			cx.TLSKeyFile = o.tlsKey
 		}
-		fmt.Println("deleted context:", name)
-		return nil
-
-	case "set":
-		fs := flag.NewFlagSet("context set", flag.ContinueOnError)
-		var name, urlStr, tok, tenant, ns, prefix string
-		var timeout int
-		fs.StringVar(&name, "name", "", "context name (required)")
-		fs.StringVar(&urlStr, "url", "", "admin URL (e.g. http://broker:8080/admin/v2)")
-		fs.StringVar(&tok, "token", "", "bearer token (optional)")
-		fs.StringVar(&tenant, "tenant", "", "tenant (e.g. amocrm)")
-		fs.StringVar(&ns, "namespace", "", "namespace (e.g. core-dev)")
-		fs.StringVar(&prefix, "prefix", "", "topic name prefix filter (optional)")
-		fs.IntVar(&timeout, "timeout", 10, "HTTP timeout in seconds")
-		if err := fs.Parse(args[1:]); err != nil {
-			return err
+		if isFlagSet(fs, "tls-insecure-skip-verify") {
+			cx.TLSInsecureSkipVerify = o.tlsInsecure
 		}
-		rest := fs.Args()
-		if urlStr == "" && len(rest) > 0 {
-			urlStr = rest[0]
+		if o.tlsServerName != "" {
+			cx.TLSServerName = o.tlsServerName
 		}
-		if name == "" {
-			return errors.New("--name is required")
+		if o.authType != "" {
+			if !slices.Contains(pulsarClient.AuthTypes, o.authType) {
+				return usageErrorf("unknown --auth-type %q (supported: %s)", o.authType, strings.Join(pulsarClient.AuthTypes, ", "))
+			}
+			cx.AuthType = o.authType
 		}
-		if cfg.Contexts[name] == nil {
-			cfg.Contexts[name] = &pulsarContext.Context{Name: name}
+		setTokenSource(fs, cx, "token", o.tok, &cx.Token, pulsarClient.AuthToken)
+		setTokenSource(fs, cx, "token-file", o.tokenFile, &cx.TokenFile, pulsarClient.AuthTokenFile)
+		setTokenSource(fs, cx, "token-exec", o.tokenExec, &cx.TokenExec, pulsarClient.AuthTokenExec)
+		if o.tokenExecTTL > 0 {
+			cx.TokenExecTTLSec = o.tokenExecTTL
 		}
-		cx := cfg.Contexts[name]
-		if urlStr != "" {
-			cx.AdminURL = strings.TrimRight(urlStr, "/")
+		if o.oauthIssuer != "" {
+			cx.OAuth2IssuerURL = o.oauthIssuer
 		}
-		if tok != "" {
-			cx.Token = tok
+		if o.oauthClientID != "" {
+			cx.OAuth2ClientID = o.oauthClientID
 		}
-		if tenant != "" {
-			cx.Tenant = tenant
+		if o.oauthClientSecret != "" {
+			cx.OAuth2ClientSecret = o.oauthClientSecret
 		}
-		if ns != "" {
-			cx.Namespace = ns
+		if o.oauthCredFile != "" {
+			cx.OAuth2CredentialsFile = o.oauthCredFile
 		}
-		if prefix != "" {
-			cx.Prefix = prefix
+		if o.oauthAudience != "" {
+			cx.OAuth2Audience = o.oauthAudience
 		}
-		if timeout > 0 {
-			cx.HTTPTimeoutSec = timeout
+		if o.oauthScope != "" {
+			cx.OAuth2Scope = o.oauthScope
 		}
-		if cfg.Current == "" {
-			cfg.Current = name
+		if o.basicUser != "" {
+			cx.BasicUser = o.basicUser
 		}
-		if err := pulsarConfig.SaveConfig(cfg); err != nil {
-			return err
+		if o.basicPassword != "" {
+			cx.BasicPassword = o.basicPassword
 		}
-		fmt.Println("saved context:", name)
 		return nil
+	}
+
+	var picked *namespaceRef
+	if o.interactive {
+		ref, err := contextSetInteractive(o.name, &o.urlStr, apply)
+		if err != nil {
+			return err
+		}
+		picked = &ref
+	}
+
+	unlock, err := pulsarConfig.LockConfig()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return err
+	}
 
-	default:
-		return fmt.Errorf("unknown subcommand: %s", sub)
+	if cfg.Contexts[o.name] == nil {
+		cfg.Contexts[o.name] = &pulsarContext.Context{Name: o.name}
+	}
+	cx := cfg.Contexts[o.name]
+	if err := apply(cx); err != nil {
+		return err
+	}
+	if picked != nil {
+		cx.Tenant, cx.Namespace = picked.Tenant, picked.Namespace
+	}
+	if countNonEmpty(o.tok, o.tokenFile, o.tokenExec) > 0 && !isTokenAuthType(cx.AuthType) {
+		fmt.Fprintf(os.Stderr, "warn: auth_type %s stays in effect, the token is saved but not sent (change with --auth-type)\n", cx.AuthType)
+	}
+	if cfg.Current == "" {
+		cfg.Current = o.name
+	}
+	if err := pulsarConfig.SaveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Println("saved context:", o.name)
+	return nil
+}
+
+// setTokenSource применяет --token/--token-file/--token-exec. Источники
+// bearer-токена взаимоисключающие: новый очищает остальные, иначе вывод
+// auth_type (token проверяется раньше token_file) оставил бы старый токен.
+// Явный auth_type другого токенного вида переключается на новый; "" очищает
+// поле.
+func setTokenSource(fs *flag.FlagSet, cx *pulsarContext.Context, name, val string, dst *string, typ string) {
+	if !isFlagSet(fs, name) {
+		return
+	}
+	explicit := isFlagSet(fs, "auth-type")
+	switch {
+	case val != "":
+		cx.Token, cx.TokenFile, cx.TokenExec = "", "", ""
+		if cx.AuthType != "" && isTokenAuthType(cx.AuthType) && !explicit {
+			cx.AuthType = typ
+		}
+	case cx.AuthType == typ && !explicit:
+		// источник очищен — auth_type снова выводится из полей
+		cx.AuthType = ""
+	}
+	*dst = val
+}
+
+func isTokenAuthType(typ string) bool {
+	switch typ {
+	case "", pulsarClient.AuthToken, pulsarClient.AuthTokenFile, pulsarClient.AuthTokenExec:
+		return true
+	}
+	return false
+}
+
+func countNonEmpty(vals ...string) int {
+	n := 0
+	for _, v := range vals {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// contextSetInteractive спрашивает admin URL (если не задан --url) и даёт
+// выбрать tenant и namespace. Конфиг здесь не блокируется: пока человек
+// выбирает, другие команды могут его писать.
+func contextSetInteractive(name string, urlStr *string, apply func(*pulsarContext.Context) error) (namespaceRef, error) {
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return namespaceRef{}, err
+	}
+	cx := &pulsarContext.Context{Name: name}
+	if stored := cfg.Contexts[name]; stored != nil {
+		c := *stored
+		cx = &c
+	}
+	in := bufio.NewReader(os.Stdin)
+	for *urlStr == "" {
+		if cx.AdminURL != "" {
+			fmt.Fprintf(os.Stderr, "admin URL [%s]: ", cx.AdminURL)
+		} else {
+			fmt.Fprint(os.Stderr, "admin URL (e.g. http://broker:8080/admin/v2): ")
+		}
+		v, err := readLine(in)
+		if err != nil {
+			return namespaceRef{}, err
+		}
+		if v == "" {
+			v = cx.AdminURL
+		}
+		*urlStr = v
+	}
+	if err := apply(cx); err != nil {
+		return namespaceRef{}, err
+	}
+	if err := pulsarConfig.ResolveSecrets(cx); err != nil {
+		return namespaceRef{}, err
+	}
+	tenant, ns, err := pickNamespace(in, cx)
+	if err != nil {
+		return namespaceRef{}, err
 	}
+	return namespaceRef{Tenant: tenant, Namespace: ns}, nil
 }
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"testing"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+func TestContextSetTokenSources(t *testing.T) {
+	tests := []struct {
+		name string
+		cx   pulsarContext.Context
+		args []string
+		want pulsarContext.Context
+	}{
+		{
+			name: "token-file replaces static token",
+			cx:   pulsarContext.Context{Token: "old"},
+			args: []string{"--token-file", "/tmp/t"},
+			want: pulsarContext.Context{TokenFile: "/tmp/t"},
+		},
+		{
+			name: "explicit token auth_type follows the new source",
+			cx:   pulsarContext.Context{AuthType: pulsarClient.AuthTokenFile, TokenFile: "/tmp/t"},
+			args: []string{"--token-exec", "echo t"},
+			want: pulsarContext.Context{AuthType: pulsarClient.AuthTokenExec, TokenExec: "echo t"},
+		},
+		{
+			name: "explicit --auth-type wins",
+			cx:   pulsarContext.Context{Token: "old"},
+			args: []string{"--token-file", "/tmp/t", "--auth-type", pulsarClient.AuthToken},
+			want: pulsarContext.Context{AuthType: pulsarClient.AuthToken, TokenFile: "/tmp/t"},
+		},
+		{
+			name: "empty value clears and restores inference",
+			cx:   pulsarContext.Context{AuthType: pulsarClient.AuthTokenExec, TokenExec: "echo t", Token: "keep"},
+			args: []string{"--token-exec", ""},
+			want: pulsarContext.Context{Token: "keep"},
+		},
+		{
+			name: "non-token auth_type is kept",
+			cx:   pulsarContext.Context{AuthType: pulsarClient.AuthBasic, BasicUser: "u"},
+			args: []string{"--token", "t"},
+			want: pulsarContext.Context{AuthType: pulsarClient.AuthBasic, BasicUser: "u", Token: "t"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet("context set")
+			o := bindContextSetFlags(fs)
+			if err := parseFlags(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			cx := tt.cx
+			if o.authType != "" {
+				cx.AuthType = o.authType
+			}
+			setTokenSource(fs, &cx, "token", o.tok, &cx.Token, pulsarClient.AuthToken)
+			setTokenSource(fs, &cx, "token-file", o.tokenFile, &cx.TokenFile, pulsarClient.AuthTokenFile)
+			setTokenSource(fs, &cx, "token-exec", o.tokenExec, &cx.TokenExec, pulsarClient.AuthTokenExec)
+			if cx.AuthType != tt.want.AuthType || cx.Token != tt.want.Token ||
+				cx.TokenFile != tt.want.TokenFile || cx.TokenExec != tt.want.TokenExec || cx.BasicUser != tt.want.BasicUser {
+				t.Errorf("got auth_type=%q token=%q token_file=%q token_exec=%q, want %q %q %q %q",
+					cx.AuthType, cx.Token, cx.TokenFile, cx.TokenExec,
+					tt.want.AuthType, tt.want.Token, tt.want.TokenFile, tt.want.TokenExec)
+			}
+		})
+	}
+}
@@ -5,185 +5,510 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	pulsarClient "puls/cmd/client"
-	pulsarConfig "puls/cmd/config"
 )
 
+// deleteEmptyTopicsFlags — флаги delete-empty-topics.
+type deleteEmptyTopicsFlags struct {
+	g                     *globalFlags
+	includeInternal       bool
+	dry                   bool
+	includeNonPersistent  bool
+	parallel              int
+	rate                  float64
+	require, snapshotPath string
+	idleFor               time.Duration
+	force, yes            bool
+	ff                    *filterFlags
+	sf                    *scopeFlags
+}
+
+func bindDeleteEmptyTopicsFlags(fs *flag.FlagSet) *deleteEmptyTopicsFlags {
+	o := &deleteEmptyTopicsFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.BoolVar(&o.includeInternal, "include-internal", false, "include system/internal topics")
+	fs.BoolVar(&o.includeNonPersistent, "include-non-persistent", false, "also check non-persistent:// topics")
+	fs.BoolVar(&o.dry, "dry-run", true, "only print what would be deleted, don't delete")
+	fs.IntVar(&o.parallel, "parallel", 8, "max parallel stats/delete requests")
+	fs.Float64Var(&o.rate, "rate", 0, "max admin API requests per second for both phases (0 = unlimited)")
+	fs.StringVar(&o.require, "require", strings.Join(defaultDeleteCriteria, ","),
+		"comma-separated deletion criteria: "+strings.Join(deleteCriteria, ", "))
+	fs.DurationVar(&o.idleFor, "idle-for", 0, "also require no publishes for this long (from internal stats), e.g. 72h")
+	fs.BoolVar(&o.force, "force", false, "delete with force=true, disconnecting clients; without --require also drops no-producers/no-consumers from the defaults")
+	fs.BoolVar(&o.yes, "yes", false, "don't ask for confirmation before deleting")
+	fs.StringVar(&o.snapshotPath, "snapshot", "", "where to write the pre-delete snapshot (default: snapshots/ next to the config)")
+	o.ff = bindFilterFlags(fs, false)
+	o.sf = bindScopeFlags(fs, o.g)
+	return o
+}
+
 func CmdDeleteEmptyTopics(args []string) error {
-	fs := flag.NewFlagSet("delete-empty-topics", flag.ContinueOnError)
-	var ctxName, tenantOverride, nsOverride, prefixOverride string
-	var includeInternal bool
-	var dry bool
-	var verbose bool
-
-	fs.StringVar(&ctxName, "context", "", "context name (optional)")
-	fs.StringVar(&tenantOverride, "tenant", "", "override tenant (optional)")
-	fs.StringVar(&nsOverride, "namespace", "", "override namespace (optional)")
-	fs.StringVar(&prefixOverride, "prefix", "", "topic name prefix filter (optional, overrides context prefix)")
-	fs.BoolVar(&includeInternal, "include-internal", false, "include system/internal topics")
-	fs.BoolVar(&dry, "dry-run", true, "only print what would be deleted, don't delete")
-	fs.BoolVar(&verbose, "verbose", false, "print detailed progress")
-
-	if err := fs.Parse(args); err != nil {
+	fs := newFlagSet("delete-empty-topics")
+	o := bindDeleteEmptyTopicsFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
 		return err
 	}
-
-	cfg, err := pulsarConfig.LoadConfig()
+	if o.parallel < 1 {
+		return usageErrorf("--parallel must be at least 1")
+	}
+	// --force снимает no-producers/no-consumers только из умолчаний:
+	// то, что указано в --require явно, не выбрасываем молча
+	crit, err := parseDeleteCriteria(o.require, o.force && !isFlagSet(fs, "require"))
 	if err != nil {
 		return err
 	}
+	if o.idleFor < 0 {
+		return usageErrorf("--idle-for must not be negative")
+	}
 
-	cx, err := pulsarConfig.MustContext(cfg, ctxName)
+	cx, err := o.g.loadContext()
 	if err != nil {
 		return err
 	}
-
-	tenant := cx.Tenant
-	if tenantOverride != "" {
-		tenant = tenantOverride
+	if !o.dry {
+		if err := checkWritable(cx); err != nil {
+			return err
+		}
 	}
-	ns := cx.Namespace
-	if nsOverride != "" {
-		ns = nsOverride
+	filter, err := o.ff.build(cx)
+	if err != nil {
+		return err
 	}
-	prefix := cx.Prefix
-	if prefixOverride != "" {
-		prefix = prefixOverride
+	protected, err := newProtectedTopics(cx)
+	if err != nil {
+		return err
 	}
+	tenant, ns := cx.Tenant, cx.Namespace
+	verbose := o.g.verbose
 
 	if verbose {
-		ctxLabel := ctxName
-		if ctxLabel == "" {
-			ctxLabel = cx.Name
-		}
 		fmt.Fprintf(os.Stderr,
-			"[puls] delete-empty-topics: context=%q tenant=%q namespace=%q prefix=%q includeInternal=%v dryRun=%v\n",
-			ctxLabel, tenant, ns, prefix, includeInternal, dry,
+			"[puls] delete-empty-topics: context=%q tenant=%q namespace=%q filter=[%s] criteria=%s idleFor=%s force=%v includeInternal=%v dryRun=%v\n",
+			o.g.label(cx), tenant, ns, filter, crit, o.idleFor, o.force, o.includeInternal, o.dry,
 		)
 	}
 
-	h := pulsarClient.NewHTTP(cx)
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return err
+	}
+	h.SetRateLimit(o.rate)
 	ctx := context.Background()
 
 	if verbose {
 		fmt.Fprintln(os.Stderr, "[puls] listing topics from Pulsar admin API...")
 	}
 
-	nonParts, err := pulsarClient.ListNonPartitionedTopics(ctx, h, tenant, ns, includeInternal)
+	domains := []string{pulsarClient.DomainPersistent}
+	if o.includeNonPersistent {
+		domains = append(domains, pulsarClient.DomainNonPersistent)
+	}
+	scopes, err := o.sf.resolve(ctx, h, cx)
 	if err != nil {
 		return err
 	}
-	parts, err := pulsarClient.ListPartitionedTopics(ctx, h, tenant, ns, includeInternal)
+	if verbose && len(scopes) > 1 {
+		fmt.Fprintf(os.Stderr, "[puls] scanning %d namespaces\n", len(scopes))
+	}
+	found, err := listScopes(ctx, h, scopes, domains,
+		filter.WantKind(pulsarClient.KindNonPartitioned), filter.WantKind(pulsarClient.KindPartitioned), o.includeInternal, o.parallel)
 	if err != nil {
 		return err
 	}
+	nonParts, parts := found.nonParts, found.parts
 
 	if verbose {
 		fmt.Fprintf(os.Stderr,
-			"[puls] found %d non-partitioned and %d partitioned topics (before prefix filter)\n",
+			"[puls] found %d non-partitioned and %d partitioned topics (before filter)\n",
 			len(nonParts), len(parts),
 		)
 	}
 
-	nonParts = pulsarClient.FilterTopicsByPrefix(nonParts, prefix)
-	parts = pulsarClient.FilterTopicsByPrefix(parts, prefix)
+	nonParts = filter.FilterTopics(nonParts)
+	parts = filter.FilterTopics(parts)
+	var protectedCount int
+	nonParts, protectedCount = dropProtected(nonParts, protected, verbose)
+	var n int
+	parts, n = dropProtected(parts, protected, verbose)
+	protectedCount += n
 
 	if verbose {
 		fmt.Fprintf(os.Stderr,
-			"[puls] after prefix=%q: %d non-partitioned, %d partitioned\n",
-			prefix, len(nonParts), len(parts),
+			"[puls] after filter: %d non-partitioned, %d partitioned\n",
+			len(nonParts), len(parts),
 		)
 	}
 
-	var candidatesNon []pulsarClient.TopicRef
-	var candidatesPart []pulsarClient.TopicRef
+	checkProg := newProgress("checking", len(nonParts)+len(parts))
+	onResult := func(pulsarClient.TopicBacklog) { checkProg.add(1) }
+	infos := pulsarClient.FetchBacklogsParallel(ctx, h, nonParts, pulsarClient.KindNonPartitioned, o.parallel, onResult)
+	nonCount := len(infos)
+	infos = append(infos, pulsarClient.FetchBacklogsParallel(ctx, h, parts, pulsarClient.KindPartitioned, o.parallel, onResult)...)
+	checkProg.finish()
 
-	// non-partitioned
-	for _, t := range nonParts {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[puls] checking non-partitioned %s ... ", t.FullName)
+	var candidates []deleteCandidate
+	var skipped, checkErrors int
+	for i, info := range infos {
+		kind := pulsarClient.KindNonPartitioned
+		if i >= nonCount {
+			kind = pulsarClient.KindPartitioned
 		}
-		e, b, err := pulsarClient.IsEmptyNonPartitioned(ctx, h, t)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warn: stats %s: %v\n", t.FullName, err)
+		if info.Err != nil {
+			checkErrors++
+			fmt.Fprintf(os.Stderr, "warn: stats %s: %v\n", info.Ref.FullName, info.Err)
 			continue
 		}
+		unmet := crit.unmet(info)
 		if verbose {
-			fmt.Fprintf(os.Stderr, "backlog=%d empty=%v\n", b, e)
+			fmt.Fprintf(os.Stderr, "[puls] checked %s %s: backlog=%d unmet=[%s]\n", kind, info.Ref.FullName, info.Backlog, strings.Join(unmet, ","))
 		}
-		if e {
-			candidatesNon = append(candidatesNon, t)
+		if len(unmet) > 0 {
+			skipped++
+			continue
 		}
+		candidates = append(candidates, deleteCandidate{Ref: info.Ref, Kind: kind})
 	}
 
-	// partitioned
-	for _, t := range parts {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[puls] checking partitioned %s ... ", t.FullName)
-		}
-		e, b, err := pulsarClient.IsEmptyPartitioned(ctx, h, t)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warn: partitioned-stats %s: %v\n", t.FullName, err)
-			continue
-		}
-		if verbose {
-			fmt.Fprintf(os.Stderr, "backlog=%d empty=%v\n", b, e)
-		}
-		if e {
-			candidatesPart = append(candidatesPart, t)
+	if o.idleFor > 0 && len(candidates) > 0 {
+		idleProg := newProgress("checking idle", len(candidates))
+		idle := checkIdleParallel(ctx, h, candidates, o.idleFor, o.parallel, idleProg)
+		idleProg.finish()
+		kept := candidates[:0]
+		for i, c := range candidates {
+			switch {
+			case idle[i].err != nil:
+				checkErrors++
+				fmt.Fprintf(os.Stderr, "warn: internal stats %s: %v\n", c.Ref.FullName, idle[i].err)
+			case idle[i].reason != "":
+				skipped++
+				if verbose {
+					fmt.Fprintf(os.Stderr, "[puls] keep %s: %s\n", c.Ref.FullName, idle[i].reason)
+				}
+			default:
+				kept = append(kept, c)
+			}
 		}
+		candidates = kept
 	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Kind != candidates[j].Kind {
+			return candidates[i].Kind == pulsarClient.KindNonPartitioned
+		}
+		return candidates[i].Ref.FullName < candidates[j].Ref.FullName
+	})
 
-	total := len(candidatesNon) + len(candidatesPart)
+	total := len(candidates)
 	if total == 0 {
-		fmt.Println("no empty topics found (backlog>0 or no topics match prefix)")
+		fmt.Println("no topics to delete (criteria not met or no topics match the filters)")
+		printDeleteSummary(0, 0, skipped, protectedCount, checkErrors, o.dry)
 		return nil
 	}
 
-	fmt.Printf("empty topics (backlog=0), tenant=%s namespace=%s prefix=%q:\n", tenant, ns, prefix)
-	for _, t := range candidatesNon {
-		fmt.Printf("  non-partitioned: %s\n", t.FullName)
+	where := fmt.Sprintf("tenant=%s namespace=%s", tenant, ns)
+	if len(scopes) > 1 {
+		where = fmt.Sprintf("namespaces=%d", len(scopes))
 	}
-	for _, t := range candidatesPart {
-		fmt.Printf("  partitioned:     %s\n", t.FullName)
+	fmt.Printf("topics to delete (%s), %s filter=[%s]:\n", crit.describe(o.idleFor), where, filter)
+	for _, c := range candidates {
+		if c.Kind == pulsarClient.KindNonPartitioned {
+			fmt.Printf("  non-partitioned: %s\n", c.Ref.FullName)
+		} else {
+			fmt.Printf("  partitioned:     %s\n", c.Ref.FullName)
+		}
 	}
 
-	if dry {
+	if o.dry {
 		fmt.Println("\nDRY-RUN: nothing deleted. Re-run with --dry-run=false to actually delete.")
+		printDeleteSummary(total, 0, skipped, protectedCount, checkErrors, o.dry)
 		return nil
 	}
 
+	if !o.yes {
+		prompt, expect := fmt.Sprintf("\nAbout to delete %d topics in %s/%s (context %q).", total, tenant, ns, o.g.label(cx)), ns
+		if len(scopes) > 1 {
+			// по нескольким namespace — фраза с числом топиков и tenant:
+			// голое число слишком легко перепечатать, не читая
+			prompt = fmt.Sprintf("\nAbout to delete %d topics in %d namespaces (context %q).", total, countNamespaces(candidates), o.g.label(cx))
+			expect = deleteConfirmPhrase(total, candidates)
+		}
+		if err := confirmByTyping(prompt, expect); err != nil {
+			return err
+		}
+	}
+
+	path, err := takeSnapshot(ctx, h, cx, o.snapshotPath, candidates, o.parallel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "snapshot: %s (undo with: puls restore --from %s)\n", path, path)
+
 	if verbose {
-		fmt.Fprintf(os.Stderr, "[puls] starting deletion of %d topics (non=%d, partitioned=%d)\n",
-			total, len(candidatesNon), len(candidatesPart))
+		fmt.Fprintf(os.Stderr, "[puls] starting deletion of %d topics (parallel=%d rate=%g/s)\n", total, o.parallel, o.rate)
 	}
 
-	for _, t := range candidatesNon {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[puls] deleting non-partitioned: %s\n", t.FullName)
-		}
-		if err := pulsarClient.DeleteNonPartitionedTopic(ctx, h, t); err != nil {
-			fmt.Fprintf(os.Stderr, "delete %s failed: %v\n", t.FullName, err)
+	deleteProg := newProgress("deleting", total)
+	failures := deleteTopicsParallel(ctx, h, candidates, o.force, o.parallel, deleteProg)
+	deleteProg.finish()
+
+	for i, c := range candidates {
+		if err := failures[i]; err != nil {
+			fmt.Fprintf(os.Stderr, "delete %s %s failed: %v\n", c.Kind, c.Ref.FullName, err)
+		} else if c.Kind == pulsarClient.KindNonPartitioned {
+			fmt.Println("deleted:", c.Ref.FullName)
 		} else {
-			fmt.Println("deleted:", t.FullName)
+			fmt.Println("deleted partitioned:", c.Ref.FullName)
 		}
 	}
 
-	for _, t := range candidatesPart {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[puls] deleting partitioned: %s\n", t.FullName)
-		}
-		if err := pulsarClient.DeletePartitionedTopic(ctx, h, t); err != nil {
-			fmt.Fprintf(os.Stderr, "delete partitioned %s failed: %v\n", t.FullName, err)
-		} else {
-			fmt.Println("deleted partitioned:", t.FullName)
+	failed := 0
+	for _, err := range failures {
+		if err != nil {
+			failed++
 		}
 	}
+	printDeleteSummary(total-failed, failed, skipped, protectedCount, checkErrors, o.dry)
 
 	if verbose {
 		fmt.Fprintln(os.Stderr, "[puls] delete-empty-topics finished")
 	}
-
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deletions failed", failed, total)
+	}
 	return nil
 }
+
+type deleteCandidate struct {
+	Ref  pulsarClient.TopicRef
+	Kind string
+}
+
+// deleteTopicsParallel удаляет топики пулом воркеров;
+// результат — ошибка на каждый кандидат (nil — удалён).
+func deleteTopicsParallel(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	candidates []deleteCandidate,
+	force bool,
+	parallel int,
+	prog *progress,
+) []error {
+	errs := make([]error, len(candidates))
+	runParallel(len(candidates), parallel, func(i int) {
+		c := candidates[i]
+		if c.Kind == pulsarClient.KindPartitioned {
+			errs[i] = pulsarClient.DeletePartitionedTopic(ctx, h, c.Ref, force)
+		} else {
+			errs[i] = pulsarClient.DeleteNonPartitionedTopic(ctx, h, c.Ref, force)
+		}
+		prog.add(1)
+	})
+	return errs
+}
+
+type idleResult struct {
+	reason string // непусто — топик активен
+	err    error
+}
+
+// checkIdleParallel проверяет по internal stats, что в топик не писали idleFor.
+func checkIdleParallel(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	candidates []deleteCandidate,
+	idleFor time.Duration,
+	parallel int,
+	prog *progress,
+) []idleResult {
+	res := make([]idleResult, len(candidates))
+	now := time.Now()
+	runParallel(len(candidates), parallel, func(i int) {
+		defer prog.add(1)
+		c := candidates[i]
+		if !c.Ref.IsPersistent() {
+			res[i].reason = "non-persistent topics have no publish history"
+			return
+		}
+		var since time.Time
+		var ok bool
+		if c.Kind == pulsarClient.KindPartitioned {
+			st, err := pulsarClient.GetPartitionedInternalStats(ctx, h, c.Ref)
+			if err != nil {
+				res[i].err = err
+				return
+			}
+			since, ok = st.IdleSince()
+		} else {
+			st, err := pulsarClient.GetInternalStats(ctx, h, c.Ref)
+			if err != nil {
+				res[i].err = err
+				return
+			}
+			since, ok = st.IdleSince()
+		}
+		switch {
+		case !ok:
+			res[i].reason = "current ledger has entries (recent publishes)"
+		case now.Sub(since) < idleFor:
+			res[i].reason = fmt.Sprintf("idle only since %s", since.Format(time.RFC3339))
+		}
+	})
+	return res
+}
+
+// runParallel вызывает fn(0..n-1) не более чем в parallel горутинах.
+func runParallel(n, parallel int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(1, min(parallel, n)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// критерии удаления (--require)
+const (
+	critNoBacklog       = "no-backlog"
+	critNoProducers     = "no-producers"
+	critNoConsumers     = "no-consumers"
+	critNoSubscriptions = "no-subscriptions"
+	critNoStorage       = "no-storage"
+)
+
+var deleteCriteria = []string{critNoBacklog, critNoProducers, critNoConsumers, critNoSubscriptions, critNoStorage}
+
+var defaultDeleteCriteria = []string{critNoBacklog, critNoProducers, critNoConsumers}
+
+type deleteCriteriaSet []string
+
+// parseDeleteCriteria разбирает --require; с dropClients требования
+// no-producers/no-consumers снимаются. Пустой набор — ошибка: иначе
+// кандидатом стал бы любой топик, прошедший фильтр.
+func parseDeleteCriteria(v string, dropClients bool) (deleteCriteriaSet, error) {
+	var out deleteCriteriaSet
+	for _, c := range strings.Split(v, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		known := false
+		for _, k := range deleteCriteria {
+			if c == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, usageErrorf("unknown criterion %q in --require (supported: %s)", c, strings.Join(deleteCriteria, ", "))
+		}
+		if dropClients && (c == critNoProducers || c == critNoConsumers) {
+			continue
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		return nil, usageErrorf("--require leaves no deletion criteria (supported: %s)", strings.Join(deleteCriteria, ", "))
+	}
+	return out, nil
+}
+
+// unmet — какие критерии топик не прошёл (пусто — можно удалять).
+func (cs deleteCriteriaSet) unmet(info pulsarClient.TopicBacklog) []string {
+	var out []string
+	st := info.Stats
+	for _, c := range cs {
+		ok := true
+		switch c {
+		case critNoBacklog:
+			ok = info.Empty
+		case critNoProducers:
+			ok = st == nil || len(st.Publishers) == 0
+		case critNoConsumers:
+			ok = st == nil || st.ConsumerCount() == 0
+		case critNoSubscriptions:
+			ok = st == nil || len(st.Subscriptions) == 0
+		case critNoStorage:
+			ok = st == nil || st.StorageSize == 0
+		}
+		if !ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (cs deleteCriteriaSet) String() string {
+	if len(cs) == 0 {
+		return "none"
+	}
+	return strings.Join(cs, ",")
+}
+
+func (cs deleteCriteriaSet) describe(idleFor time.Duration) string {
+	s := cs.String()
+	if idleFor > 0 {
+		s += fmt.Sprintf(", idle-for=%s", idleFor)
+	}
+	return s
+}
+
+func printDeleteSummary(deleted, failed, skipped, protected, checkErrors int, dry bool) {
+	if dry {
+		fmt.Printf("summary: would delete %d, skipped %d (criteria not met), protected %d, stats errors %d\n", deleted, skipped, protected, checkErrors)
+		return
+	}
+	fmt.Printf("summary: deleted %d, failed %d, skipped %d (criteria not met), protected %d, stats errors %d\n", deleted, failed, skipped, protected, checkErrors)
+}
+
+func countNamespaces(candidates []deleteCandidate) int {
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		seen[c.Ref.Tenant+"/"+c.Ref.Namespace] = true
+	}
+	return len(seen)
+}
+
+// deleteConfirmPhrase — что ввести для удаления по нескольким namespace:
+// "delete N topics in TENANT" или "delete N topics in K tenants".
+func deleteConfirmPhrase(total int, candidates []deleteCandidate) string {
+	tenants := map[string]bool{}
+	for _, c := range candidates {
+		tenants[c.Ref.Tenant] = true
+	}
+	if len(tenants) == 1 {
+		return fmt.Sprintf("delete %d topics in %s", total, candidates[0].Ref.Tenant)
+	}
+	return fmt.Sprintf("delete %d topics in %d tenants", total, len(tenants))
+}
+
+// dropProtected убирает protected-топики до проверки stats.
+func dropProtected(topics []pulsarClient.TopicRef, pt protectedTopics, verbose bool) ([]pulsarClient.TopicRef, int) {
+	if len(pt) == 0 {
+		return topics, 0
+	}
+	out := topics[:0]
+	for _, t := range topics {
+		if pt.match(t) {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[puls] protected, skipping %s\n", t.FullName)
+			}
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, len(topics) - len(out)
+}
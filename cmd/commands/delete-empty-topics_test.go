@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	pulsarClient "puls/cmd/client"
+)
+
+func TestDeleteConfirmPhrase(t *testing.T) {
+	cand := func(tenant, ns string) deleteCandidate {
+		return deleteCandidate{Ref: pulsarClient.TopicRef{Tenant: tenant, Namespace: ns}}
+	}
+	tests := []struct {
+		name  string
+		cands []deleteCandidate
+		want  string
+	}{
+		{name: "one tenant", cands: []deleteCandidate{cand("t", "a"), cand("t", "b")}, want: "delete 2 topics in t"},
+		{name: "several tenants", cands: []deleteCandidate{cand("t", "a"), cand("u", "a"), cand("u", "b")}, want: "delete 3 topics in 2 tenants"},
+	}
+	for _, tt := range tests {
+		if got := deleteConfirmPhrase(len(tt.cands), tt.cands); got != tt.want {
+			t.Errorf("%s: deleteConfirmPhrase = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseDeleteCriteria(t *testing.T) {
+	tests := []struct {
+		in          string
+		dropClients bool
+		want        string
+		wantErr     bool
+	}{
+		{in: "no-backlog,no-producers,no-consumers", want: "no-backlog,no-producers,no-consumers"},
+		{in: "no-backlog,no-producers,no-consumers", dropClients: true, want: "no-backlog"},
+		{in: " no-storage , no-subscriptions ", want: "no-storage,no-subscriptions"},
+		{in: "", wantErr: true},
+		{in: " , ", wantErr: true},
+		{in: "no-producers,no-consumers", dropClients: true, wantErr: true},
+		{in: "no-backlog,no-such", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDeleteCriteria(tt.in, tt.dropClients)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDeleteCriteria(%q, %v) error = %v, wantErr %v", tt.in, tt.dropClients, err, tt.wantErr)
+			continue
+		}
+		if err == nil && strings.Join(got, ",") != tt.want {
+			t.Errorf("parseDeleteCriteria(%q, %v) = %v, want %s", tt.in, tt.dropClients, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+func tenantsList(args []string) error {
+	return runNameList("tenants list", args, func(ctx context.Context, h *pulsarClient.HttpClient, cx *pulsarContext.Context) ([]string, error) {
+		return pulsarClient.ListTenants(ctx, h)
+	})
+}
+
+func namespacesList(args []string) error {
+	return runNameList("namespaces list", args, func(ctx context.Context, h *pulsarClient.HttpClient, cx *pulsarContext.Context) ([]string, error) {
+		if cx.Tenant == "" {
+			return nil, usageErrorf("tenant not set: use --tenant or set it in the context")
+		}
+		return pulsarClient.ListNamespaces(ctx, h, cx.Tenant)
+	})
+}
+
+func clustersList(args []string) error {
+	return runNameList("clusters list", args, func(ctx context.Context, h *pulsarClient.HttpClient, cx *pulsarContext.Context) ([]string, error) {
+		return pulsarClient.ListClusters(ctx, h)
+	})
+}
+
+// nameListFlags — флаги tenants/namespaces/clusters list.
+type nameListFlags struct {
+	g      *globalFlags
+	output string
+}
+
+func bindNameListFlags(fs *flag.FlagSet) *nameListFlags {
+	o := &nameListFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.StringVar(&o.output, "output", outputTable, "output format: table (one name per line), json")
+	return o
+}
+
+// runNameList — общий каркас для *-list: по имени на строку или json-массив.
+func runNameList(
+	path string,
+	args []string,
+	fetch func(context.Context, *pulsarClient.HttpClient, *pulsarContext.Context) ([]string, error),
+) error {
+	fs := newFlagSet(path)
+	o := bindNameListFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if o.output != outputTable && o.output != outputJSON {
+		return usageErrorf("unknown output format %q (supported: %s, %s)", o.output, outputTable, outputJSON)
+	}
+	cx, err := o.g.loadContext()
+	if err != nil {
+		return err
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return err
+	}
+	names, err := fetch(context.Background(), h, cx)
+	if err != nil {
+		return err
+	}
+	if o.output == outputJSON {
+		if names == nil {
+			names = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(names)
+	}
+	for _, n := range names {
+		fmt.Println(n)
+	}
+	return nil
+}
+
+// pickNamespace — для `context set --interactive`: выбрать tenant и
+// namespace из /tenants и /namespaces/{tenant}. Если список недоступен
+// (например, /tenants требует superuser) — имя вводится вручную.
+func pickNamespace(in *bufio.Reader, cx *pulsarContext.Context) (tenant, ns string, err error) {
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return "", "", err
+	}
+	ctx := context.Background()
+
+	tenants, err := pulsarClient.ListTenants(ctx, h)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warn: %v\n", err)
+	}
+	if tenant, err = pickName(in, "tenant", tenants, cx.Tenant); err != nil {
+		return "", "", err
+	}
+
+	full, err := pulsarClient.ListNamespaces(ctx, h, tenant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warn: %v\n", err)
+	}
+	// в списке — короткие имена, без "tenant/"
+	names := make([]string, 0, len(full))
+	for _, n := range full {
+		names = append(names, parseNamespaceArg(n, tenant).Namespace)
+	}
+	current := ""
+	if tenant == cx.Tenant {
+		current = cx.Namespace
+	}
+	if ns, err = pickName(in, "namespace", names, current); err != nil {
+		return "", "", err
+	}
+	return tenant, ns, nil
+}
+
+// pickName печатает нумерованный список и читает номер или имя;
+// пустой ввод — current. Без списка — просто ввод имени.
+func pickName(in *bufio.Reader, what string, items []string, current string) (string, error) {
+	if len(items) > 0 {
+		fmt.Fprintf(os.Stderr, "%ss:\n", what)
+		for i, it := range items {
+			mark := " "
+			if it == current {
+				mark = "*"
+			}
+			fmt.Fprintf(os.Stderr, "  %s %2d) %s\n", mark, i+1, it)
+		}
+	}
+	for {
+		if current != "" {
+			fmt.Fprintf(os.Stderr, "%s [%s]: ", what, current)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: ", what)
+		}
+		v, err := readLine(in)
+		if err != nil {
+			return "", err
+		}
+		switch n, convErr := strconv.Atoi(v); {
+		case v == "" && current != "":
+			return current, nil
+		case v == "":
+		case convErr == nil && len(items) > 0:
+			if n >= 1 && n <= len(items) {
+				return items[n-1], nil
+			}
+			fmt.Fprintf(os.Stderr, "no %s number %d\n", what, n)
+		default:
+			return v, nil
+		}
+	}
+}
+
+// readLine — строка без перевода; EOF без ввода — отмена.
+func readLine(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("input aborted")
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	pulsarConfig "puls/cmd/config"
+)
+
+// contextFlags — --context a,b,c и --all-contexts для read-only команд:
+// каждый контекст опрашивается параллельно со своим HttpClient.
+type contextFlags struct {
+	g   *globalFlags
+	all bool
+}
+
+func bindContextFlags(fs *flag.FlagSet, g *globalFlags) *contextFlags {
+	g.multiContext = true
+	c := &contextFlags{g: g}
+	fs.BoolVar(&c.all, "all-contexts", false, "query every context from the config concurrently (--context also takes a,b,c)")
+	return c
+}
+
+// names — контексты для опроса; nil — обычный режим с одним контекстом.
+func (c *contextFlags) names() ([]string, error) {
+	if c.all {
+		cfg, err := pulsarConfig.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.Contexts) == 0 {
+			return nil, fmt.Errorf("no contexts configured")
+		}
+		names := make([]string, 0, len(cfg.Contexts))
+		for name := range cfg.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	if !strings.Contains(c.g.ctxName, ",") {
+		return nil, nil
+	}
+	var names []string
+	for _, n := range strings.Split(c.g.ctxName, ",") {
+		if n = strings.TrimSpace(n); n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names, nil
+}
+
+// forContext — копия глобальных флагов с другим --context.
+func (c *contextFlags) forContext(name string) *globalFlags {
+	g := *c.g
+	g.ctxName = name
+	g.namespaces = slices.Clone(c.g.namespaces)
+	return &g
+}
+
+type contextResult[T any] struct {
+	Context string
+	Value   T
+	Err     error
+}
+
+// fanOut выполняет fn для каждого контекста параллельно; результаты —
+// в порядке names. Ошибка одного контекста не останавливает остальные.
+func fanOut[T any](c *contextFlags, names []string, fn func(g *globalFlags) (T, error)) []contextResult[T] {
+	res := make([]contextResult[T], len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := fn(c.forContext(name))
+			res[i] = contextResult[T]{Context: name, Value: v, Err: err}
+		}()
+	}
+	wg.Wait()
+	return res
+}
+
+// reportContextErrors печатает ошибки контекстов в stderr; error, если
+// упал хотя бы один (код выхода 1, остальные результаты уже напечатаны).
+func reportContextErrors[T any](results []contextResult[T]) error {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "error: context %s: %v\n", r.Context, r.Err)
+		}
+	}
+	if failed > 0 {
+		return &ExitError{Code: ExitFailure, Err: fmt.Errorf("%d of %d contexts failed", failed, len(results))}
+	}
+	return nil
+}
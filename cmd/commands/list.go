@@ -6,246 +6,266 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"strings"
 	"strconv"
+	"strings"
 
 	pulsarClient "puls/cmd/client"
-	pulsarConfig "puls/cmd/config"
 )
 
 type topicInfo struct {
 	Ref     pulsarClient.TopicRef
 	Backlog int64
 	Kind    string // "non-partitioned" / "partitioned"
+	Context string // заполняется только при опросе нескольких контекстов
 }
 
-func CmdList(args []string) error {
-	fs := flag.NewFlagSet("list", flag.ContinueOnError)
-	var ctxName, tenantOverride, nsOverride, prefixOverride string
-	var includeInternal bool
-	var full bool
-	var verbose bool
-	var parallel int
-	var withPartitioned bool
+// listOptions — флаги list, не зависящие от контекста.
+type listOptions struct {
+	includeInternal      bool
+	full                 bool
+	parallel             int
+	withPartitioned      bool
+	includeNonPersistent bool
+}
 
-	fs.StringVar(&ctxName, "context", "", "context name (optional)")
-	fs.StringVar(&tenantOverride, "tenant", "", "override tenant (optional)")
-	fs.StringVar(&nsOverride, "namespace", "", "override namespace (optional)")
-	fs.StringVar(&prefixOverride, "prefix", "", "topic name prefix (optional, overrides context prefix)")
-	fs.BoolVar(&includeInternal, "include-internal", false, "include system/internal topics")
-	fs.BoolVar(&full, "full", false, "show all topics (including backlog=0)")
-	fs.BoolVar(&verbose, "verbose", false, "print detailed progress to stderr")
-	fs.IntVar(&parallel, "parallel", 16, "max parallel stats requests")
-	fs.BoolVar(&withPartitioned, "with-partitioned", false, "with partitioned topics")
+// listFlags — флаги list.
+type listFlags struct {
+	g            *globalFlags
+	opts         listOptions
+	output, tmpl string
+	ff           *filterFlags
+	sf           *scopeFlags
+	cf           *contextFlags
+}
+
+func bindListFlags(fs *flag.FlagSet) *listFlags {
+	o := &listFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.BoolVar(&o.opts.includeInternal, "include-internal", false, "include system/internal topics")
+	fs.BoolVar(&o.opts.full, "full", false, "show all topics (including backlog=0)")
+	fs.IntVar(&o.opts.parallel, "parallel", 16, "max parallel stats requests")
+	fs.BoolVar(&o.opts.withPartitioned, "with-partitioned", false, "with partitioned topics")
+	fs.BoolVar(&o.opts.includeNonPersistent, "include-non-persistent", false, "also list non-persistent:// topics (no backlog, shown with --full)")
+	fs.StringVar(&o.output, "output", outputTable, "output format: table, json, jsonl, csv, yaml, template")
+	fs.StringVar(&o.tmpl, "template", "", "Go text/template for --output template (e.g. '{{.FullName}} {{.Backlog}}')")
+	o.ff = bindFilterFlags(fs, true)
+	o.sf = bindScopeFlags(fs, o.g)
+	o.cf = bindContextFlags(fs, o.g)
+	return o
+}
 
-	if err := fs.Parse(args); err != nil {
+func CmdList(args []string) error {
+	fs := newFlagSet("list")
+	o := bindListFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
 		return err
 	}
-
-	cfg, err := pulsarConfig.LoadConfig()
-	if err != nil {
+	if err := validateOutput(o.output, o.tmpl); err != nil {
 		return err
 	}
+	// явный --min-backlog сам решает, показывать ли нулевые
+	hideEmpty := !o.opts.full && o.ff.minBacklog == nil
 
-	cx, err := pulsarConfig.MustContext(cfg, ctxName)
+	names, err := o.cf.names()
 	if err != nil {
 		return err
 	}
-
-	tenant := cx.Tenant
-	if tenantOverride != "" {
-		tenant = tenantOverride
-	}
-	ns := cx.Namespace
-	if nsOverride != "" {
-		ns = nsOverride
-	}
-	prefix := cx.Prefix
-	if prefixOverride != "" {
-		prefix = prefixOverride
-	}
-
-	if verbose {
-		ctxLabel := ctxName
-		if ctxLabel == "" {
-			ctxLabel = cx.Name
+	var result []topicInfo
+	var multi bool
+	var ctxErr error
+	if names == nil {
+		if result, multi, err = listTopics(o.g, o.ff, o.sf, o.opts); err != nil {
+			return err
 		}
-		fmt.Fprintf(os.Stderr,
-			"[puls] list: context=%q tenant=%q namespace=%q prefix=%q includeInternal=%v full=%v parallel=%d\n",
-			ctxLabel, tenant, ns, prefix, includeInternal, full, parallel,
-		)
-	}
-
-	h := pulsarClient.NewHTTP(cx)
-	ctx := context.Background()
-
-	if verbose {
-		fmt.Fprintln(os.Stderr, "[puls] listing topics from Pulsar admin API...")
+	} else {
+		type listed struct {
+			topics []topicInfo
+			multi  bool
+		}
+		results := fanOut(o.cf, names, func(cg *globalFlags) (listed, error) {
+			topics, multi, err := listTopics(cg, o.ff, o.sf, o.opts)
+			return listed{topics, multi}, err
+		})
+		for _, r := range results {
+			for _, ti := range r.Value.topics {
+				ti.Context = r.Context
+				result = append(result, ti)
+			}
+			multi = multi || r.Value.multi
+		}
+		ctxErr = reportContextErrors(results)
 	}
+	withContext := names != nil
 
-	var result []topicInfo
-	result, err = listNonPartitioned(
-		ctx,
-		h,
-		tenant,
-		ns,
-		includeInternal,
-		verbose,
-		prefix,
-		full,
-		parallel,
-	)
-	if err != nil {
-		return err
-	}
+	// сортируем по имени (стабильный вывод); контексты и namespace — группами
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Ref, result[j].Ref
+		if result[i].Context != result[j].Context {
+			return result[i].Context < result[j].Context
+		}
+		if multi && (a.Tenant != b.Tenant || a.Namespace != b.Namespace) {
+			return a.Tenant+"/"+a.Namespace < b.Tenant+"/"+b.Namespace
+		}
+		return a.FullName < b.FullName
+	})
 
-	// если указали флаг
-	if withPartitioned {
-		parts, err := pulsarClient.ListPartitionedTopics(ctx, h, tenant, ns, includeInternal)
-		if err != nil {
+	if o.output != outputTable {
+		if err := writeTopicRecords(os.Stdout, o.output, o.tmpl, toTopicRecords(result), withContext); err != nil {
 			return err
 		}
-	
-		if verbose {
-			fmt.Fprintf(
-				os.Stderr,
-				"[puls] found %d partitioned topics (before prefix filter)\n",
-				len(parts),
-			)
-		}
-	
-		parts = pulsarClient.FilterTopicsByPrefix(parts, prefix)
-	
-		if verbose {
-			fmt.Fprintf(
-				os.Stderr,
-				"[puls] after prefix=%q: %d partitioned\n",
-				prefix,
-				len(parts),
-			)
-			fmt.Fprintf(os.Stderr, "[puls] fetching stats for partitioned topics in parallel (parallel=%d)...\n", parallel)
-		}
-	
-		// --- параллельно тянем бэклоги ---
-		partInfos := pulsarClient.FetchPartitionedBacklogsParallel(ctx, h, parts, parallel)
-		// partitioned
-		for _, info := range partInfos {
-			if info.Err != nil {
-				fmt.Fprintf(os.Stderr, "warn: partitioned-stats %s: %v\n", info.Ref.FullName, info.Err)
-				continue
-			}
-			if verbose {
-				fmt.Fprintf(os.Stderr, "[puls] stats partitioned %s: backlog=%d empty=%v\n",
-					info.Ref.FullName, info.Backlog, info.Empty)
-			}
-			if !full && info.Backlog == 0 {
-				continue
-			}
-			result = append(result, topicInfo{
-				Ref:     info.Ref,
-				Backlog: info.Backlog,
-				Kind:    "partitioned",
-			})
+		if o.g.verbose {
+			fmt.Fprintf(os.Stderr, "[puls] list finished, printed %d topics\n", len(result))
 		}
+		return ctxErr
 	}
 
 	if len(result) == 0 {
-		if full {
-			fmt.Println("no topics found (check tenant/namespace/prefix)")
+		if !hideEmpty {
+			fmt.Println("no topics found (check tenant/namespace/filters)")
 		} else {
 			fmt.Println("no topics with backlog > 0 found")
 		}
-		return nil
+		return ctxErr
 	}
 
-	// сортируем по имени (стабильный вывод)
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Ref.FullName < result[j].Ref.FullName
-	})
-
-	printList(result)
+	printList(result, multi, withContext)
 
-	if verbose {
+	if o.g.verbose {
 		fmt.Fprintf(os.Stderr, "[puls] list finished, printed %d topics\n", len(result))
 	}
 
-	return nil
+	return ctxErr
 }
 
-func listNonPartitioned(
-	ctx context.Context,
-	h *pulsarClient.HttpClient,
-	tenant string,
-	ns string,
-	includeInternal bool,
-	verbose bool,
-	prefix string,
-	full bool,
-	parallel int,
-) ([]topicInfo, error) {
-	var result []topicInfo
-	nonParts, err := pulsarClient.ListNonPartitionedTopics(ctx, h, tenant, ns, includeInternal)
+// listTopics — топики одного контекста с backlog после фильтров.
+// multi — обходилось больше одного namespace.
+func listTopics(g *globalFlags, ff *filterFlags, sf *scopeFlags, opts listOptions) (result []topicInfo, multi bool, err error) {
+	cx, err := g.loadContext()
+	if err != nil {
+		return nil, false, err
+	}
+	filter, err := ff.build(cx)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	tenant, ns := cx.Tenant, cx.Namespace
+	verbose := g.verbose
+	parallel := opts.parallel
+	// --kind partitioned включает partitioned и без --with-partitioned
+	withPartitioned := (opts.withPartitioned || ff.kindRequested(pulsarClient.KindPartitioned)) &&
+		filter.WantKind(pulsarClient.KindPartitioned)
+	hideEmpty := !opts.full && filter.MinBacklog == nil
+
 	if verbose {
-		fmt.Fprintf(
-			os.Stderr,
-			"[puls] found %d non-partitioned topics (before prefix filter)\n",
-			len(nonParts),
+		fmt.Fprintf(os.Stderr,
+			"[puls] list: context=%q tenant=%q namespace=%q filter=[%s] includeInternal=%v full=%v parallel=%d\n",
+			g.label(cx), tenant, ns, filter, opts.includeInternal, opts.full, parallel,
 		)
 	}
 
-	nonParts = pulsarClient.FilterTopicsByPrefix(nonParts, prefix)
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return nil, false, err
+	}
+	ctx := context.Background()
+
 	if verbose {
-		fmt.Fprintf(
-			os.Stderr,
-			"[puls] after prefix=%q: %d non-partitioned\n",
-			prefix, len(nonParts),
-		)
+		fmt.Fprintln(os.Stderr, "[puls] listing topics from Pulsar admin API...")
+	}
+
+	scopes, err := sf.resolve(ctx, h, cx)
+	if err != nil {
+		return nil, false, err
+	}
+	multi = len(scopes) > 1
+	if verbose && multi {
+		fmt.Fprintf(os.Stderr, "[puls] scanning %d namespaces\n", len(scopes))
+	}
+
+	domains := []string{pulsarClient.DomainPersistent}
+	if opts.includeNonPersistent {
+		domains = append(domains, pulsarClient.DomainNonPersistent)
+	}
+	found, err := listScopes(ctx, h, scopes, domains,
+		filter.WantKind(pulsarClient.KindNonPartitioned), withPartitioned, opts.includeInternal, parallel)
+	if err != nil {
+		return nil, false, err
 	}
 
 	if verbose {
-		fmt.Fprintf(os.Stderr, "[puls] fetching stats for non-partitioned topics in parallel (parallel=%d)...\n", parallel)
+		fmt.Fprintf(os.Stderr,
+			"[puls] found %d non-partitioned and %d partitioned topics (before filter)\n",
+			len(found.nonParts), len(found.parts),
+		)
 	}
-	nonInfos := pulsarClient.FetchNonPartitionedBacklogsParallel(ctx, h, nonParts, parallel)
-	// non-partitioned
-	for _, info := range nonInfos {
-		if info.Err != nil {
-			fmt.Fprintf(os.Stderr, "warn: stats %s: %v\n", info.Ref.FullName, info.Err)
-			continue
-		}
-		if verbose {
-			fmt.Fprintf(os.Stderr, "[puls] stats non-partitioned %s: backlog=%d empty=%v\n",
-				info.Ref.FullName, info.Backlog, info.Empty)
-		}
-		if !full && info.Backlog == 0 {
-			continue
+	nonParts := filter.FilterTopics(found.nonParts)
+	parts := filter.FilterTopics(found.parts)
+	if verbose {
+		fmt.Fprintf(os.Stderr, "[puls] after filter: %d non-partitioned, %d partitioned\n", len(nonParts), len(parts))
+		fmt.Fprintf(os.Stderr, "[puls] fetching stats in parallel (parallel=%d)...\n", parallel)
+	}
+
+	collect := func(infos []pulsarClient.TopicBacklog, kind string) {
+		for _, info := range infos {
+			if info.Err != nil {
+				fmt.Fprintf(os.Stderr, "warn: stats %s: %v\n", info.Ref.FullName, info.Err)
+				continue
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[puls] stats %s %s: backlog=%d empty=%v\n",
+					kind, info.Ref.FullName, info.Backlog, info.Empty)
+			}
+			if hideEmpty && info.Backlog == 0 || !filter.MatchBacklog(info.Backlog) {
+				continue
+			}
+			result = append(result, topicInfo{
+				Ref:     info.Ref,
+				Backlog: info.Backlog,
+				Kind:    kind,
+			})
 		}
-		result = append(result, topicInfo{
-			Ref:     info.Ref,
-			Backlog: info.Backlog,
-			Kind:    "non-partitioned",
-		})
 	}
-	return result, nil
+	collect(pulsarClient.FetchNonPartitionedBacklogsParallel(ctx, h, nonParts, parallel), pulsarClient.KindNonPartitioned)
+	collect(pulsarClient.FetchPartitionedBacklogsParallel(ctx, h, parts, parallel), pulsarClient.KindPartitioned)
+	return result, multi, nil
 }
 
 // helpers
 
-func printList(result []topicInfo) {
+func printList(result []topicInfo, withNamespace, withContext bool) {
 	// вычисляем максимальную длину имени — чтобы красиво выровнять колонку
 	maxNameLen := 0
+	maxNsLen := len("NAMESPACE")
+	maxCtxLen := len("CONTEXT")
 	for _, ti := range result {
 	    if l := len(ti.Ref.FullName); l > maxNameLen {
 	        maxNameLen = l
 	    }
+	    maxNsLen = max(maxNsLen, len(ti.Ref.Tenant)+1+len(ti.Ref.Namespace))
+	    maxCtxLen = max(maxCtxLen, len(ti.Context))
+	}
+
+	// при обходе нескольких namespace — колонка NAMESPACE слева,
+	// при нескольких контекстах — ещё левее CONTEXT
+	nsCol := func(v string) string { return "" }
+	if withNamespace {
+		nsCol = func(v string) string { return fmt.Sprintf("%-*s | ", maxNsLen, v) }
+	}
+	ctxCol := func(v string) string { return "" }
+	if withContext {
+		ctxCol = func(v string) string { return fmt.Sprintf("%-*s | ", maxCtxLen, v) }
 	}
 	
 	// заголовок
-	fmt.Printf("%-*s | %12s | %s\n", maxNameLen, "TOPIC", "BACKLOG", "KIND")
+	fmt.Printf("%s%s%-*s | %12s | %s\n", ctxCol("CONTEXT"), nsCol("NAMESPACE"), maxNameLen, "TOPIC", "BACKLOG", "KIND")
 	
 	// простая «линия» под заголовком
+	if withContext {
+		fmt.Print(strings.Repeat("-", maxCtxLen) + "-+-")
+	}
+	if withNamespace {
+		fmt.Print(strings.Repeat("-", maxNsLen) + "-+-")
+	}
 	fmt.Printf("%s-+-%s-+-%s\n",
 	    strings.Repeat("-", maxNameLen),
 	    strings.Repeat("-", 12),
@@ -255,10 +275,12 @@ func printList(result []topicInfo) {
 	// строки с данными
 	for _, ti := range result {
 		kindShort := "part"
-		if ti.Kind == "non-partitioned" {
+		if ti.Kind == pulsarClient.KindNonPartitioned {
 			kindShort = "nonpar"
 		}
-		fmt.Printf("%-*s | %12s | %s\n",
+		fmt.Printf("%s%s%-*s | %12s | %s\n",
+			ctxCol(ti.Context),
+			nsCol(ti.Ref.Tenant+"/"+ti.Ref.Namespace),
 			maxNameLen,
 			ti.Ref.FullName,
 			formatIntWithSep(ti.Backlog),
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// форматы вывода для --output
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputJSONL    = "jsonl"
+	outputCSV      = "csv"
+	outputYAML     = "yaml"
+	outputTemplate = "template"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputJSONL, outputCSV, outputYAML, outputTemplate}
+
+// topicRecord — плоское представление topicInfo для машинного вывода.
+type topicRecord struct {
+	FullName  string `json:"full_name"`
+	Tenant    string `json:"tenant"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Backlog   int64  `json:"backlog"`
+	Kind      string `json:"kind"`
+	Domain    string `json:"domain"`            // persistent | non-persistent
+	Context   string `json:"context,omitempty"` // только при опросе нескольких контекстов
+}
+
+// csv: колонка context — последней и только для нескольких контекстов,
+// чтобы не сдвигать колонки у существующих скриптов.
+func (r topicRecord) csvHeader(withContext bool) []string {
+	h := []string{"full_name", "tenant", "namespace", "name", "backlog", "kind", "domain"}
+	if withContext {
+		h = append(h, "context")
+	}
+	return h
+}
+
+func (r topicRecord) csvRow(withContext bool) []string {
+	row := []string{r.FullName, r.Tenant, r.Namespace, r.Name, strconv.FormatInt(r.Backlog, 10), r.Kind, r.Domain}
+	if withContext {
+		row = append(row, r.Context)
+	}
+	return row
+}
+
+func toTopicRecords(result []topicInfo) []topicRecord {
+	out := make([]topicRecord, 0, len(result))
+	for _, ti := range result {
+		out = append(out, topicRecord{
+			FullName:  ti.Ref.FullName,
+			Domain:    ti.Ref.Domain,
+			Tenant:    ti.Ref.Tenant,
+			Namespace: ti.Ref.Namespace,
+			Name:      ti.Ref.Name,
+			Backlog:   ti.Backlog,
+			Kind:      ti.Kind,
+			Context:   ti.Context,
+		})
+	}
+	return out
+}
+
+func validateOutput(format, tmpl string) error {
+	switch format {
+	case outputTable, outputJSON, outputJSONL, outputCSV, outputYAML:
+		return nil
+	case outputTemplate:
+		if tmpl == "" {
+			return usageErrorf("--output %s requires --template", outputTemplate)
+		}
+		// шаблон проверяем до обхода топиков, а не после полного скана
+		_, err := parseOutputTemplate(tmpl)
+		return err
+	default:
+		return usageErrorf("unknown output format %q (supported: %s)", format, strings.Join(outputFormats, ", "))
+	}
+}
+
+// parseOutputTemplate разбирает --template и пробно применяет его к пустой
+// записи: так и опечатка в имени поля ({{.Backlg}}) видна сразу.
+func parseOutputTemplate(tmpl string) (*template.Template, error) {
+	t, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return nil, usageErrorf("parse --template: %w", err)
+	}
+	if err := t.Execute(io.Discard, topicRecord{}); err != nil {
+		return nil, usageErrorf("--template: %w", err)
+	}
+	return t, nil
+}
+
+// writeTopicRecords печатает записи в машинном формате (всё, кроме table).
+// withContext — записи из нескольких контекстов (колонка context в csv).
+func writeTopicRecords(w io.Writer, format, tmpl string, records []topicRecord, withContext bool) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+
+	case outputJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(topicRecord{}.csvHeader(withContext)); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(r.csvRow(withContext)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case outputYAML:
+		return writeYAML(w, records)
+
+	case outputTemplate:
+		t, err := parseOutputTemplate(tmpl)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := t.Execute(w, r); err != nil {
+				return err
+			}
+			// удобнее для пайпов: каждая запись на своей строке
+			if !strings.HasSuffix(tmpl, "\n") {
+				fmt.Fprintln(w)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeYAML — минимальный YAML без внешних зависимостей:
+// список плоских объектов, строки всегда в двойных кавычках.
+func writeYAML(w io.Writer, records []topicRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, r := range records {
+		_, err := fmt.Fprintf(w,
+			"- full_name: %s\n  tenant: %s\n  namespace: %s\n  name: %s\n  backlog: %d\n  kind: %s\n  domain: %s\n",
+			strconv.Quote(r.FullName),
+			strconv.Quote(r.Tenant),
+			strconv.Quote(r.Namespace),
+			strconv.Quote(r.Name),
+			r.Backlog,
+			strconv.Quote(r.Kind),
+			strconv.Quote(r.Domain),
+		)
+		if err == nil && r.Context != "" {
+			_, err = fmt.Fprintf(w, "  context: %s\n", strconv.Quote(r.Context))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
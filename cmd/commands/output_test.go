@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateOutputTemplate(t *testing.T) {
+	tests := []struct {
+		format, tmpl string
+		wantErr      bool
+	}{
+		{format: outputJSON},
+		{format: outputTemplate, tmpl: "{{.FullName}} {{.Backlog}} {{.Context}}"},
+		{format: outputTemplate, wantErr: true},
+		{format: outputTemplate, tmpl: "{{.FullName", wantErr: true},
+		{format: outputTemplate, tmpl: "{{.Backlg}}", wantErr: true},
+		{format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateOutput(tt.format, tt.tmpl)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateOutput(%q, %q) = %v, wantErr %v", tt.format, tt.tmpl, err, tt.wantErr)
+		}
+		var ee *ExitError
+		if err != nil && (!errors.As(err, &ee) || ee.Code != ExitUsage) {
+			t.Errorf("validateOutput(%q, %q): want usage error, got %v", tt.format, tt.tmpl, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progress — счётчик «сделано N из M» в stderr. На терминале обновляет
+// одну строку, иначе печатает строку на каждые 10%.
+type progress struct {
+	label string
+	total int
+	w     io.Writer
+	tty   bool
+
+	mu      sync.Mutex
+	done    int
+	lastPct int
+	printed time.Time
+}
+
+func newProgress(label string, total int) *progress {
+	return &progress{label: label, total: total, w: os.Stderr, tty: isTerminal(os.Stderr)}
+}
+
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	if p.total == 0 {
+		return
+	}
+	pct := p.done * 100 / p.total
+	if p.tty {
+		if p.done < p.total && time.Since(p.printed) < 100*time.Millisecond {
+			return
+		}
+		p.printed = time.Now()
+		fmt.Fprintf(p.w, "\r[puls] %s %d/%d (%d%%)", p.label, p.done, p.total, pct)
+		return
+	}
+	if step := pct / 10 * 10; step > p.lastPct {
+		p.lastPct = step
+		fmt.Fprintf(p.w, "[puls] %s %d/%d (%d%%)\n", p.label, p.done, p.total, pct)
+	}
+}
+
+// finish завершает строку прогресса на терминале.
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty && p.total > 0 {
+		fmt.Fprintln(p.w)
+	}
+}
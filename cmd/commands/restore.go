@@ -0,0 +1,270 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// restoreFlags — флаги restore.
+type restoreFlags struct {
+	g                      *globalFlags
+	from, position         string
+	dry, overwritePolicies bool
+	ff                     *filterFlags
+}
+
+func bindRestoreFlags(fs *flag.FlagSet) *restoreFlags {
+	o := &restoreFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.StringVar(&o.from, "from", "", "snapshot file written by a destructive command (required)")
+	fs.StringVar(&o.position, "position", "latest", "where recreated subscriptions start: latest, earliest, snapshot (saved mark-delete positions)")
+	fs.BoolVar(&o.dry, "dry-run", true, "only print what would be restored, don't change anything")
+	fs.BoolVar(&o.overwritePolicies, "overwrite-policies", false, "also apply saved policies to topics that still exist (replaces their current values)")
+	o.ff = bindFilterFlags(fs, false)
+	return o
+}
+
+func CmdRestore(args []string) error {
+	fs := newFlagSet("restore")
+	o := bindRestoreFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if o.from == "" {
+		return usageErrorf("--from is required")
+	}
+	switch o.position {
+	case "latest", "earliest", "snapshot":
+	default:
+		return usageErrorf("unknown --position %q (supported: latest, earliest, snapshot)", o.position)
+	}
+
+	snap, err := pulsarClient.ReadSnapshot(o.from)
+	if err != nil {
+		return err
+	}
+	cx, err := o.g.loadContext()
+	if err != nil {
+		return err
+	}
+	if !o.dry {
+		if err := checkWritable(cx); err != nil {
+			return err
+		}
+	}
+	filter, err := o.ff.build(cx)
+	if err != nil {
+		return err
+	}
+	if snap.AdminURL != "" && strings.TrimRight(snap.AdminURL, "/") != strings.TrimRight(cx.AdminURL, "/") {
+		fmt.Fprintf(os.Stderr, "warn: snapshot was taken on %s (context %q), restoring to %s\n", snap.AdminURL, snap.Context, cx.AdminURL)
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	fmt.Printf("snapshot %s: %d topics, taken %s by %q\n", o.from, len(snap.Topics), snap.CreatedAt.Format(time.RFC3339), snap.Command)
+	var restored, failed int
+	for _, ts := range snap.Topics {
+		ref, err := pulsarClient.ParseTopicArg(ts.Topic, cx)
+		if err != nil {
+			return err
+		}
+		if !filter.MatchName(ref) || !filter.WantKind(ts.Kind) {
+			continue
+		}
+		if o.dry {
+			printRestorePlan(ts, o.position, o.overwritePolicies)
+			restored++
+			continue
+		}
+		if err := restoreTopic(ctx, h, ref, ts, o.position, o.overwritePolicies); err != nil {
+			fmt.Fprintf(os.Stderr, "restore %s failed: %v\n", ts.Topic, err)
+			failed++
+			continue
+		}
+		restored++
+	}
+
+	if o.dry {
+		fmt.Println("\nDRY-RUN: nothing restored. Re-run with --dry-run=false to apply.")
+		fmt.Printf("summary: would restore %d\n", restored)
+		return nil
+	}
+	fmt.Printf("summary: restored %d, failed %d\n", restored, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d topics failed to restore", failed, restored+failed)
+	}
+	return nil
+}
+
+func printRestorePlan(ts pulsarClient.TopicSnapshot, position string, overwritePolicies bool) {
+	if ts.Kind == pulsarClient.KindPartitioned {
+		fmt.Printf("  partitioned (%d):  %s\n", ts.Partitions, ts.Topic)
+	} else {
+		fmt.Printf("  non-partitioned: %s\n", ts.Topic)
+	}
+	for _, s := range ts.Subscriptions {
+		fmt.Printf("    subscription %s (at %s)\n", s.Name, position)
+	}
+	for _, name := range slices.Sorted(maps.Keys(ts.Policies)) {
+		var v bytes.Buffer
+		if err := json.Compact(&v, ts.Policies[name]); err != nil {
+			v.Write(ts.Policies[name])
+		}
+		when := " (if the topic is created)"
+		if overwritePolicies {
+			when = ""
+		}
+		fmt.Printf("    policy %s = %s%s\n", name, v.String(), when)
+	}
+}
+
+// restoreTopic: топик → политики → подписки. Уже существующие
+// топики и подписки не трогаются: политики ставятся только на созданный
+// топик (или с overwritePolicies), иначе restore после `subscriptions
+// delete` затёр бы текущие retention/TTL значениями из снимка.
+func restoreTopic(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	ref pulsarClient.TopicRef,
+	ts pulsarClient.TopicSnapshot,
+	position string,
+	overwritePolicies bool,
+) error {
+	var exists bool
+	var err error
+	if ts.Kind == pulsarClient.KindPartitioned {
+		exists, err = pulsarClient.CreatePartitionedTopic(ctx, h, ref, ts.Partitions)
+	} else {
+		exists, err = pulsarClient.CreateNonPartitionedTopic(ctx, h, ref)
+	}
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Println("exists:", ts.Topic)
+	} else {
+		fmt.Println("created:", ts.Topic)
+	}
+
+	if exists && !overwritePolicies && len(ts.Policies) > 0 {
+		fmt.Printf("  policies kept (topic exists; --overwrite-policies to apply %d saved)\n", len(ts.Policies))
+	} else {
+		for _, name := range slices.Sorted(maps.Keys(ts.Policies)) {
+			if err := pulsarClient.SetTopicPolicy(ctx, h, ref, name, ts.Policies[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, s := range ts.Subscriptions {
+		// без сохранённых позиций (non-persistent) — как latest
+		if position != "snapshot" || len(s.Cursors) == 0 {
+			id := pulsarClient.LatestMessageID
+			if position == "earliest" {
+				id = pulsarClient.EarliestMessageID
+			}
+			if err := createSubscription(ctx, h, ref, s.Name, id); err != nil {
+				return err
+			}
+			continue
+		}
+		// у partitioned позиции свои в каждой партиции. Берём mark-delete,
+		// а не read: между ними выданные, но не подтверждённые сообщения,
+		// и с read position они бы молча пропали (подтверждённое последним
+		// может прийти повторно — это безопаснее потери)
+		for _, c := range s.Cursors {
+			id, err := pulsarClient.ParsePosition(c.MarkDeletePosition)
+			if err != nil {
+				return fmt.Errorf("subscription %s: %w", s.Name, err)
+			}
+			target := ref
+			if c.Partition >= 0 {
+				target = pulsarClient.PartitionRef(ref, c.Partition)
+			}
+			if err := createSubscription(ctx, h, target, s.Name, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func createSubscription(ctx context.Context, h *pulsarClient.HttpClient, ref pulsarClient.TopicRef, sub string, id pulsarClient.MessageID) error {
+	exists, err := pulsarClient.CreateSubscription(ctx, h, ref, sub, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Printf("  subscription exists: %s %s\n", ref.FullName, sub)
+	} else {
+		fmt.Printf("  subscription created: %s %s\n", ref.FullName, sub)
+	}
+	return nil
+}
+
+// takeSnapshot снимает топики перед деструктивной командой и пишет файл;
+// без path — в snapshots/ рядом с конфигом. Возвращает путь к файлу.
+// При ошибке снимка удалять нельзя: вызывающий должен остановиться.
+func takeSnapshot(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	cx *pulsarContext.Context,
+	path string,
+	topics []deleteCandidate,
+	parallel int,
+) (string, error) {
+	snap := &pulsarClient.Snapshot{
+		Version:   pulsarClient.SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Context:   cx.Name,
+		AdminURL:  cx.AdminURL,
+		Command:   pulsarClient.CommandLine(),
+		Topics:    make([]pulsarClient.TopicSnapshot, len(topics)),
+	}
+	errs := make([]error, len(topics))
+	prog := newProgress("snapshot", len(topics))
+	runParallel(len(topics), parallel, func(i int) {
+		snap.Topics[i], errs[i] = pulsarClient.SnapshotTopic(ctx, h, topics[i].Ref, topics[i].Kind)
+		prog.add(1)
+	})
+	prog.finish()
+	for i, err := range errs {
+		if err != nil {
+			return "", fmt.Errorf("snapshot %s: %w (nothing changed)", topics[i].Ref.FullName, err)
+		}
+	}
+
+	if path == "" {
+		dir, err := pulsarConfig.Dir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(dir, "snapshots")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", err
+		}
+		name := fmt.Sprintf("%s-%s.json", cx.Name, snap.CreatedAt.Format("20060102-150405"))
+		path = filepath.Join(dir, name)
+	}
+	if err := pulsarClient.WriteSnapshot(path, snap); err != nil {
+		return "", fmt.Errorf("write snapshot: %w (nothing changed)", err)
+	}
+	return path, nil
+}
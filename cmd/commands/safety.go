@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// Защита от случайных деструктивных действий: readonly-контексты,
+// список protected-топиков и подтверждение вводом имени namespace.
+
+// checkWritable — ошибка, если контекст помечен readonly.
+func checkWritable(cx *pulsarContext.Context) error {
+	if cx.ReadOnly {
+		return fmt.Errorf("context %q is read-only: destructive commands are disabled (puls context set --name %s --readonly=false)", cx.Name, cx.Name)
+	}
+	return nil
+}
+
+// protectedTopics — шаблоны из cx.Protected (синтаксис как у --include).
+type protectedTopics []pulsarClient.Pattern
+
+func newProtectedTopics(cx *pulsarContext.Context) (protectedTopics, error) {
+	var out protectedTopics
+	for _, s := range cx.Protected {
+		p, err := pulsarClient.ParsePattern(s, false)
+		if err != nil {
+			return nil, fmt.Errorf("protected: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (pt protectedTopics) match(t pulsarClient.TopicRef) bool {
+	for _, p := range pt {
+		if p.Match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotProtected — ошибка, если топик в списке protected контекста.
+func checkNotProtected(cx *pulsarContext.Context, t pulsarClient.TopicRef) error {
+	pt, err := newProtectedTopics(cx)
+	if err != nil {
+		return err
+	}
+	if pt.match(t) {
+		return fmt.Errorf("topic %s is protected in context %q", t.FullName, cx.Name)
+	}
+	return nil
+}
+
+// confirmByTyping просит ввести expect; без терминала на stdin — отказ
+// (в скриптах нужен --yes).
+func confirmByTyping(prompt, expect string) error {
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("confirmation required but stdin is not a terminal; re-run with --yes")
+	}
+	fmt.Fprintf(os.Stderr, "%s\nType %q to confirm: ", prompt, expect)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("confirmation aborted")
+	}
+	if strings.TrimSpace(line) != expect {
+		return fmt.Errorf("confirmation did not match %q, nothing changed", expect)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// namespaceRef — один tenant/namespace, который сканирует команда.
+type namespaceRef struct {
+	Tenant    string
+	Namespace string
+}
+
+func (n namespaceRef) String() string {
+	return n.Tenant + "/" + n.Namespace
+}
+
+// parseNamespaceArg понимает "ns" (tenant из контекста) и "tenant/ns".
+func parseNamespaceArg(v, tenant string) namespaceRef {
+	if t, ns, ok := strings.Cut(v, "/"); ok {
+		return namespaceRef{Tenant: t, Namespace: ns}
+	}
+	return namespaceRef{Tenant: tenant, Namespace: v}
+}
+
+// scopeFlags — --all-namespaces / --all-tenants плюс повторяемый --namespace
+// для команд, которые обходят несколько namespace.
+type scopeFlags struct {
+	g             *globalFlags
+	allNamespaces bool
+	allTenants    bool
+}
+
+func bindScopeFlags(fs *flag.FlagSet, g *globalFlags) *scopeFlags {
+	g.multiNamespace = true
+	s := &scopeFlags{g: g}
+	fs.BoolVar(&s.allNamespaces, "all-namespaces", false, "scan every namespace of the tenant")
+	fs.BoolVar(&s.allTenants, "all-tenants", false, "scan every namespace of every tenant")
+	return s
+}
+
+// resolve — список namespace для сканирования: /tenants и /namespaces/{tenant}
+// для --all-*, иначе --namespace (можно несколько) или namespace контекста.
+func (s *scopeFlags) resolve(ctx context.Context, h *pulsarClient.HttpClient, cx *pulsarContext.Context) ([]namespaceRef, error) {
+	var tenants []string
+	switch {
+	case s.allTenants:
+		var err error
+		if tenants, err = pulsarClient.ListTenants(ctx, h); err != nil {
+			return nil, err
+		}
+	case s.allNamespaces:
+		if cx.Tenant == "" {
+			return nil, usageErrorf("--all-namespaces needs a tenant (context or --tenant)")
+		}
+		tenants = []string{cx.Tenant}
+	case len(s.g.namespaces) > 1:
+		var out []namespaceRef
+		for _, v := range s.g.namespaces {
+			ref := parseNamespaceArg(v, cx.Tenant)
+			if ref.Tenant == "" || ref.Namespace == "" {
+				return nil, usageErrorf("--namespace %q: tenant not set (use tenant/ns)", v)
+			}
+			out = append(out, ref)
+		}
+		return out, nil
+	default:
+		return []namespaceRef{{Tenant: cx.Tenant, Namespace: cx.Namespace}}, nil
+	}
+
+	var out []namespaceRef
+	for _, t := range tenants {
+		names, err := pulsarClient.ListNamespaces(ctx, h, t)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			out = append(out, parseNamespaceArg(n, t))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out, nil
+}
+
+// scopeTopics — топики всех namespace по видам.
+type scopeTopics struct {
+	nonParts []pulsarClient.TopicRef
+	parts    []pulsarClient.TopicRef
+}
+
+// listScopes параллельно листает топики namespace × domains. При одном
+// namespace ошибка возвращается; при нескольких — печатается и namespace
+// пропускается, чтобы один закрытый namespace не ломал весь обход.
+func listScopes(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	scopes []namespaceRef,
+	domains []string,
+	wantNon, wantPart, includeInternal bool,
+	parallel int,
+) (scopeTopics, error) {
+	type job struct {
+		ns          namespaceRef
+		domain      string
+		partitioned bool
+	}
+	var jobs []job
+	for _, ns := range scopes {
+		for _, d := range domains {
+			if wantNon {
+				jobs = append(jobs, job{ns, d, false})
+			}
+			if wantPart {
+				jobs = append(jobs, job{ns, d, true})
+			}
+		}
+	}
+	topics := make([][]pulsarClient.TopicRef, len(jobs))
+	errs := make([]error, len(jobs))
+	runParallel(len(jobs), parallel, func(i int) {
+		j := jobs[i]
+		topics[i], errs[i] = pulsarClient.ListTopics(ctx, h, j.domain, j.ns.Tenant, j.ns.Namespace, j.partitioned, includeInternal)
+	})
+
+	var res scopeTopics
+	for i, j := range jobs {
+		if err := errs[i]; err != nil {
+			if len(scopes) == 1 {
+				return res, err
+			}
+			fmt.Fprintf(os.Stderr, "warn: namespace %s: %v\n", j.ns, err)
+			continue
+		}
+		if j.partitioned {
+			res.parts = append(res.parts, topics[i]...)
+		} else {
+			res.nonParts = append(res.nonParts, topics[i]...)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,426 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+	pulsarConfig "puls/cmd/config"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// metricDesc — описание метрики для # HELP / # TYPE.
+type metricDesc struct {
+	name string
+	typ  string // gauge / counter
+	help string
+}
+
+// порядок вывода метрик в /metrics
+var metricDescs = []metricDesc{
+	{"puls_up", "gauge", "1 if the last scrape of the context succeeded"},
+	{"puls_scrapes_total", "counter", "Scrapes of the admin API per context"},
+	{"puls_scrape_errors_total", "counter", "Failed admin API calls per context and stage (list, stats)"},
+	{"puls_scrape_duration_seconds", "gauge", "Duration of the last scrape"},
+	{"puls_last_scrape_timestamp_seconds", "gauge", "Unix time of the last finished scrape"},
+	{"puls_topics", "gauge", "Topics matched by the context prefix and filters"},
+	{"puls_topic_backlog", "gauge", "Sum of msgBacklog over subscriptions of the topic"},
+	{"puls_topic_msg_rate_in", "gauge", "Messages per second published to the topic"},
+	{"puls_topic_msg_rate_out", "gauge", "Messages per second dispatched from the topic"},
+	{"puls_topic_msg_throughput_in_bytes", "gauge", "Bytes per second published to the topic"},
+	{"puls_topic_msg_throughput_out_bytes", "gauge", "Bytes per second dispatched from the topic"},
+	{"puls_topic_storage_size_bytes", "gauge", "Storage size of the topic"},
+	{"puls_topic_producers", "gauge", "Connected producers"},
+	{"puls_topic_consumers", "gauge", "Connected consumers over all subscriptions"},
+	{"puls_topic_subscriptions", "gauge", "Subscriptions of the topic"},
+	{"puls_subscription_backlog", "gauge", "msgBacklog of the subscription"},
+	{"puls_subscription_unacked_messages", "gauge", "Unacknowledged messages of the subscription"},
+	{"puls_subscription_msg_rate_out", "gauge", "Messages per second dispatched to the subscription"},
+	{"puls_subscription_consumers", "gauge", "Connected consumers of the subscription"},
+}
+
+type metricSample struct {
+	name   string
+	labels []string // пары ключ, значение
+	value  float64
+}
+
+// contextScraper периодически снимает stats одного контекста
+// и хранит последний набор сэмплов.
+type contextScraper struct {
+	name   string
+	cx     *pulsarContext.Context
+	h      *pulsarClient.HttpClient
+	filter *pulsarClient.TopicFilter
+
+	includeInternal bool
+	withPartitioned bool
+	parallel        int
+
+	// ошибка конфигурации контекста: он не скрейпится, но виден
+	// как puls_up=0 и puls_scrape_errors_total{stage="config"}
+	configErr error
+
+	mu       sync.Mutex
+	samples  []metricSample
+	up       bool
+	scrapes  int64
+	errors   map[string]int64 // stage → count
+	duration time.Duration
+	last     time.Time
+}
+
+// serveMetricsFlags — флаги serve-metrics.
+type serveMetricsFlags struct {
+	g                                *globalFlags
+	listen                           string
+	interval                         time.Duration
+	parallel                         int
+	includeInternal, withPartitioned bool
+	ff                               *filterFlags
+}
+
+func bindServeMetricsFlags(fs *flag.FlagSet) *serveMetricsFlags {
+	o := &serveMetricsFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.StringVar(&o.listen, "listen", ":9888", "address to serve /metrics on")
+	fs.DurationVar(&o.interval, "interval", 30*time.Second, "scrape interval")
+	fs.IntVar(&o.parallel, "parallel", 8, "max parallel stats requests per context")
+	fs.BoolVar(&o.includeInternal, "include-internal", false, "include system/internal topics")
+	fs.BoolVar(&o.withPartitioned, "with-partitioned", true, "with partitioned topics")
+	o.ff = bindFilterFlags(fs, false)
+	return o
+}
+
+func CmdServeMetrics(args []string) error {
+	fs := newFlagSet("serve-metrics")
+	o := bindServeMetricsFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if o.interval < time.Second {
+		return usageErrorf("--interval must be at least 1s")
+	}
+
+	scrapers, err := newScrapers(o.g, o.ff, o.includeInternal, o.withPartitioned, o.parallel)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, s := range scrapers {
+		go s.run(ctx, o.interval)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, scrapers)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "puls exporter: see /metrics")
+	})
+	srv := &http.Server{Addr: o.listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	names := make([]string, 0, len(scrapers))
+	for _, s := range scrapers {
+		names = append(names, s.name)
+	}
+	fmt.Fprintf(os.Stderr, "[puls] serving metrics on %s/metrics (contexts: %s, every %s)\n",
+		o.listen, strings.Join(names, ", "), o.interval)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// newScrapers: --context — только он, иначе все контексты из конфига
+// (или контекст из окружения, если конфиг пуст).
+func newScrapers(g *globalFlags, ff *filterFlags, includeInternal, withPartitioned bool, parallel int) ([]*contextScraper, error) {
+	cfg, err := pulsarConfig.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{g.ctxName}
+	if g.ctxName == "" && len(cfg.Contexts) > 0 {
+		names = names[:0]
+		for name := range cfg.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var out []*contextScraper
+	for _, name := range names {
+		cg := *g
+		cg.ctxName = name
+		// сломанный контекст не должен мешать остальным: пишем в лог
+		// и отдаём его как puls_up=0
+		broken := func(cx *pulsarContext.Context, err error) {
+			fmt.Fprintf(os.Stderr, "error: context %q: %v (not scraped)\n", name, err)
+			if cx == nil {
+				cx = &pulsarContext.Context{Name: name}
+			}
+			out = append(out, &contextScraper{
+				name:      cg.label(cx),
+				cx:        cx,
+				configErr: err,
+				errors:    map[string]int64{"config": 1},
+			})
+		}
+		cx, err := cg.loadContext()
+		if err != nil {
+			broken(nil, err)
+			continue
+		}
+		filter, err := ff.build(cx)
+		if err != nil {
+			broken(cx, err)
+			continue
+		}
+		h, err := pulsarClient.NewHTTP(cx)
+		if err != nil {
+			broken(cx, err)
+			continue
+		}
+		out = append(out, &contextScraper{
+			name:            cg.label(cx),
+			cx:              cx,
+			h:               h,
+			filter:          filter,
+			includeInternal: includeInternal,
+			withPartitioned: withPartitioned,
+			parallel:        parallel,
+			errors:          map[string]int64{},
+		})
+	}
+	return out, nil
+}
+
+func (s *contextScraper) run(ctx context.Context, interval time.Duration) {
+	if s.configErr != nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		scrapeCtx, cancel := context.WithTimeout(ctx, interval)
+		s.scrape(scrapeCtx)
+		cancel()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *contextScraper) scrape(ctx context.Context) {
+	start := time.Now()
+	var samples []metricSample
+	errs := map[string]int64{}
+	up := true
+	topics := 0
+
+	collect := func(kind string, list func() ([]pulsarClient.TopicRef, error), fetch func([]pulsarClient.TopicRef) []pulsarClient.TopicBacklog) {
+		refs, err := list()
+		if err != nil {
+			errs["list"]++
+			up = false
+			fmt.Fprintf(os.Stderr, "warn: %s: list %s topics: %v\n", s.name, kind, err)
+			return
+		}
+		refs = s.filter.FilterTopics(refs)
+		for _, info := range fetch(refs) {
+			if info.Err != nil {
+				errs["stats"]++
+				continue
+			}
+			topics++
+			samples = append(samples, s.topicSamples(info, kind)...)
+		}
+	}
+
+	if s.filter.WantKind(pulsarClient.KindNonPartitioned) {
+		collect(pulsarClient.KindNonPartitioned,
+			func() ([]pulsarClient.TopicRef, error) {
+				return pulsarClient.ListNonPartitionedTopics(ctx, s.h, s.cx.Tenant, s.cx.Namespace, s.includeInternal)
+			},
+			func(refs []pulsarClient.TopicRef) []pulsarClient.TopicBacklog {
+				return pulsarClient.FetchNonPartitionedBacklogsParallel(ctx, s.h, refs, s.parallel)
+			})
+	}
+	if s.withPartitioned && s.filter.WantKind(pulsarClient.KindPartitioned) {
+		collect(pulsarClient.KindPartitioned,
+			func() ([]pulsarClient.TopicRef, error) {
+				return pulsarClient.ListPartitionedTopics(ctx, s.h, s.cx.Tenant, s.cx.Namespace, s.includeInternal)
+			},
+			func(refs []pulsarClient.TopicRef) []pulsarClient.TopicBacklog {
+				return pulsarClient.FetchPartitionedBacklogsParallel(ctx, s.h, refs, s.parallel)
+			})
+	}
+
+	samples = append(samples, metricSample{"puls_topics", s.baseLabels(), float64(topics)})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// при неудачном листинге оставляем прошлые значения топиков,
+	// puls_up=0 и счётчик ошибок сообщат о проблеме
+	if up {
+		s.samples = samples
+	}
+	s.up = up
+	s.scrapes++
+	for stage, n := range errs {
+		s.errors[stage] += n
+	}
+	s.duration = time.Since(start)
+	s.last = time.Now()
+}
+
+func (s *contextScraper) baseLabels() []string {
+	return []string{
+		"context", s.name,
+		"tenant", s.cx.Tenant,
+		"namespace", s.cx.Namespace,
+		"prefix", s.cx.Prefix,
+	}
+}
+
+func (s *contextScraper) topicSamples(info pulsarClient.TopicBacklog, kind string) []metricSample {
+	st := info.Stats
+	labels := append(s.baseLabels(), "topic", info.Ref.Name, "kind", kind)
+	out := []metricSample{
+		{"puls_topic_backlog", labels, float64(info.Backlog)},
+		{"puls_topic_msg_rate_in", labels, st.MsgRateIn},
+		{"puls_topic_msg_rate_out", labels, st.MsgRateOut},
+		{"puls_topic_msg_throughput_in_bytes", labels, st.MsgThroughputIn},
+		{"puls_topic_msg_throughput_out_bytes", labels, st.MsgThroughputOut},
+		{"puls_topic_storage_size_bytes", labels, float64(st.StorageSize)},
+		{"puls_topic_producers", labels, float64(len(st.Publishers))},
+		{"puls_topic_consumers", labels, float64(st.ConsumerCount())},
+		{"puls_topic_subscriptions", labels, float64(len(st.Subscriptions))},
+	}
+	for name, sub := range st.Subscriptions {
+		sl := append(labels[:len(labels):len(labels)], "subscription", name, "type", sub.Type)
+		out = append(out,
+			metricSample{"puls_subscription_backlog", sl, float64(sub.MsgBacklog)},
+			metricSample{"puls_subscription_unacked_messages", sl, float64(sub.UnackedMessages)},
+			metricSample{"puls_subscription_msg_rate_out", sl, sub.MsgRateOut},
+			metricSample{"puls_subscription_consumers", sl, float64(len(sub.Consumers))},
+		)
+	}
+	return out
+}
+
+// statusSamples — служебные метрики скрапера (под s.mu).
+func (s *contextScraper) statusSamples() []metricSample {
+	ctxLabel := []string{"context", s.name}
+	up := 0.0
+	if s.up {
+		up = 1
+	}
+	out := []metricSample{
+		{"puls_up", ctxLabel, up},
+		{"puls_scrapes_total", ctxLabel, float64(s.scrapes)},
+		{"puls_scrape_duration_seconds", ctxLabel, s.duration.Seconds()},
+	}
+	if !s.last.IsZero() {
+		out = append(out, metricSample{"puls_last_scrape_timestamp_seconds", ctxLabel, float64(s.last.Unix())})
+	}
+	// стадии выводим всегда, чтобы rate() работал с нуля
+	for _, stage := range []string{"config", "list", "stats"} {
+		out = append(out, metricSample{"puls_scrape_errors_total", []string{"context", s.name, "stage", stage}, float64(s.errors[stage])})
+	}
+	return out
+}
+
+// writeMetrics печатает сэмплы всех контекстов в текстовом формате Prometheus.
+func writeMetrics(w io.Writer, scrapers []*contextScraper) {
+	byName := map[string][]metricSample{}
+	for _, s := range scrapers {
+		s.mu.Lock()
+		for _, m := range s.statusSamples() {
+			byName[m.name] = append(byName[m.name], m)
+		}
+		for _, m := range s.samples {
+			byName[m.name] = append(byName[m.name], m)
+		}
+		s.mu.Unlock()
+	}
+
+	var b strings.Builder
+	for _, d := range metricDescs {
+		samples := byName[d.name]
+		if len(samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.typ)
+		for _, m := range samples {
+			b.WriteString(m.name)
+			b.WriteString(formatLabels(m.labels))
+			b.WriteByte(' ')
+			b.WriteString(formatMetricValue(m.value))
+			b.WriteByte('\n')
+		}
+	}
+	io.WriteString(w, b.String())
+}
+
+// formatMetricValue: целые без экспоненты (счётчики, байты, timestamp).
+func formatMetricValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatLabels(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// subsCommon — флаги, общие для всех подкоманд subscriptions.
+type subsCommon struct {
+	fs    *flag.FlagSet
+	g     *globalFlags
+	topic string
+	sub   string
+	dry   bool
+	yes   bool
+
+	destructive bool
+	cx          *pulsarContext.Context // заполняется в resolve
+}
+
+func bindSubsFlags(fs *flag.FlagSet, destructive bool) *subsCommon {
+	c := &subsCommon{fs: fs, destructive: destructive}
+	c.g = addGlobalFlags(c.fs)
+	c.fs.StringVar(&c.topic, "topic", "", "topic name (persistent://tenant/ns/name, non-persistent://tenant/ns/name or just name)")
+	if destructive {
+		c.fs.StringVar(&c.sub, "sub", "", "subscription name (required)")
+		c.fs.BoolVar(&c.dry, "dry-run", true, "only print what would be done, don't change anything")
+		c.fs.BoolVar(&c.yes, "yes", false, "don't ask for confirmation before applying")
+	}
+	return c
+}
+
+func bindSubsListFlags(fs *flag.FlagSet) *subsCommon         { return bindSubsFlags(fs, false) }
+func bindSubsClearBacklogFlags(fs *flag.FlagSet) *subsCommon { return bindSubsFlags(fs, true) }
+
+type subsSkipFlags struct {
+	*subsCommon
+	count int64
+}
+
+func bindSubsSkipFlags(fs *flag.FlagSet) *subsSkipFlags {
+	c := &subsSkipFlags{subsCommon: bindSubsFlags(fs, true)}
+	fs.Int64Var(&c.count, "count", 0, "number of messages to skip (required)")
+	return c
+}
+
+type subsResetCursorFlags struct {
+	*subsCommon
+	timeArg, msgIDArg string
+}
+
+func bindSubsResetCursorFlags(fs *flag.FlagSet) *subsResetCursorFlags {
+	c := &subsResetCursorFlags{subsCommon: bindSubsFlags(fs, true)}
+	fs.StringVar(&c.timeArg, "time", "", "reset to publish time: RFC3339 timestamp or duration ago (e.g. 1h)")
+	fs.StringVar(&c.msgIDArg, "message-id", "", "reset to message id <ledgerId>:<entryId>")
+	return c
+}
+
+type subsDeleteFlags struct {
+	*subsCommon
+	force        bool
+	snapshotPath string
+}
+
+func bindSubsDeleteFlags(fs *flag.FlagSet) *subsDeleteFlags {
+	c := &subsDeleteFlags{subsCommon: bindSubsFlags(fs, true)}
+	fs.BoolVar(&c.force, "force", false, "delete even if the subscription has connected consumers")
+	fs.StringVar(&c.snapshotPath, "snapshot", "", "where to write the pre-delete snapshot (default: snapshots/ next to the config)")
+	return c
+}
+
+// resolve загружает контекст и топик после fs.Parse.
+func (c *subsCommon) resolve() (*pulsarClient.HttpClient, pulsarClient.TopicRef, error) {
+	if c.topic == "" {
+		return nil, pulsarClient.TopicRef{}, usageErrorf("--topic is required")
+	}
+	cx, err := c.g.loadContext()
+	if err != nil {
+		return nil, pulsarClient.TopicRef{}, err
+	}
+	ref, err := pulsarClient.ParseTopicArg(c.topic, cx)
+	if err != nil {
+		return nil, pulsarClient.TopicRef{}, err
+	}
+	if c.destructive && !c.dry {
+		if err := checkWritable(cx); err != nil {
+			return nil, pulsarClient.TopicRef{}, err
+		}
+		if err := checkNotProtected(cx, ref); err != nil {
+			return nil, pulsarClient.TopicRef{}, err
+		}
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return nil, pulsarClient.TopicRef{}, err
+	}
+	c.cx = cx
+	return h, ref, nil
+}
+
+// confirm — подтверждение вводом имени подписки, как в delete-empty-topics.
+func (c *subsCommon) confirm(action string, ref pulsarClient.TopicRef) error {
+	if c.yes {
+		return nil
+	}
+	prompt := fmt.Sprintf("About to %s: subscription %s on %s (context %q).", action, c.sub, ref.FullName, c.g.label(c.cx))
+	return confirmByTyping(prompt, c.sub)
+}
+
+func subscriptionsList(args []string) error {
+	c := bindSubsListFlags(newFlagSet("subscriptions list"))
+	if err := parseFlags(c.fs, args); err != nil {
+		return err
+	}
+	h, ref, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	subs, err := pulsarClient.ListSubscriptions(context.Background(), h, ref)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		fmt.Printf("no subscriptions on %s\n", ref.FullName)
+		return nil
+	}
+
+	printSubscriptionTable(subs)
+	return nil
+}
+
+func subscriptionsClearBacklog(args []string) error {
+	c := bindSubsClearBacklogFlags(newFlagSet("subscriptions clear-backlog"))
+	if err := parseFlags(c.fs, args); err != nil {
+		return err
+	}
+	if c.sub == "" {
+		return usageErrorf("--sub is required")
+	}
+	h, ref, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	if c.dry {
+		fmt.Printf("DRY-RUN: would clear backlog of %s on %s. Re-run with --dry-run=false to apply.\n", c.sub, ref.FullName)
+		return nil
+	}
+	if err := c.confirm("clear backlog", ref); err != nil {
+		return err
+	}
+	if err := pulsarClient.ClearSubscriptionBacklog(context.Background(), h, ref, c.sub); err != nil {
+		return err
+	}
+	fmt.Printf("cleared backlog: %s %s\n", ref.FullName, c.sub)
+	return nil
+}
+
+func subscriptionsSkip(args []string) error {
+	c := bindSubsSkipFlags(newFlagSet("subscriptions skip"))
+	if err := parseFlags(c.fs, args); err != nil {
+		return err
+	}
+	if c.sub == "" {
+		return usageErrorf("--sub is required")
+	}
+	if c.count <= 0 {
+		return usageErrorf("--count must be > 0")
+	}
+	h, ref, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	if c.dry {
+		fmt.Printf("DRY-RUN: would skip %d messages of %s on %s. Re-run with --dry-run=false to apply.\n", c.count, c.sub, ref.FullName)
+		return nil
+	}
+	if err := c.confirm(fmt.Sprintf("skip %d messages", c.count), ref); err != nil {
+		return err
+	}
+	if err := pulsarClient.SkipSubscriptionMessages(context.Background(), h, ref, c.sub, c.count); err != nil {
+		return err
+	}
+	fmt.Printf("skipped %d messages: %s %s\n", c.count, ref.FullName, c.sub)
+	return nil
+}
+
+func subscriptionsResetCursor(args []string) error {
+	c := bindSubsResetCursorFlags(newFlagSet("subscriptions reset-cursor"))
+	if err := parseFlags(c.fs, args); err != nil {
+		return err
+	}
+	if c.sub == "" {
+		return usageErrorf("--sub is required")
+	}
+	if (c.timeArg == "") == (c.msgIDArg == "") {
+		return usageErrorf("exactly one of --time or --message-id is required")
+	}
+
+	var ts time.Time
+	var msgID pulsarClient.MessageID
+	var target string
+	if c.timeArg != "" {
+		t, err := parseResetTime(c.timeArg, time.Now())
+		if err != nil {
+			return err
+		}
+		ts = t
+		target = ts.Format(time.RFC3339)
+	} else {
+		id, err := pulsarClient.ParsePosition(c.msgIDArg)
+		if err != nil {
+			return usageErrorf("invalid --message-id: %v", err)
+		}
+		msgID = id
+		target = "message " + c.msgIDArg
+	}
+
+	h, ref, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	if c.dry {
+		fmt.Printf("DRY-RUN: would reset cursor of %s on %s to %s. Re-run with --dry-run=false to apply.\n", c.sub, ref.FullName, target)
+		return nil
+	}
+	if err := c.confirm("reset cursor to "+target, ref); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if c.timeArg != "" {
+		err = pulsarClient.ResetCursorToTime(ctx, h, ref, c.sub, ts.UnixMilli())
+	} else {
+		err = pulsarClient.ResetCursorToMessageID(ctx, h, ref, c.sub, msgID)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("cursor reset to %s: %s %s\n", target, ref.FullName, c.sub)
+	return nil
+}
+
+func subscriptionsDelete(args []string) error {
+	c := bindSubsDeleteFlags(newFlagSet("subscriptions delete"))
+	if err := parseFlags(c.fs, args); err != nil {
+		return err
+	}
+	if c.sub == "" {
+		return usageErrorf("--sub is required")
+	}
+	h, ref, err := c.resolve()
+	if err != nil {
+		return err
+	}
+	if c.dry {
+		fmt.Printf("DRY-RUN: would delete subscription %s on %s. Re-run with --dry-run=false to apply.\n", c.sub, ref.FullName)
+		return nil
+	}
+	if err := c.confirm("delete", ref); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	n, err := pulsarClient.GetPartitionCount(ctx, h, ref)
+	if err != nil {
+		return err
+	}
+	kind := pulsarClient.KindNonPartitioned
+	if n > 0 {
+		kind = pulsarClient.KindPartitioned
+	}
+	path, err := takeSnapshot(ctx, h, c.cx, c.snapshotPath, []deleteCandidate{{Ref: ref, Kind: kind}}, 1)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "snapshot: %s\n", path)
+	if err := pulsarClient.DeleteSubscription(ctx, h, ref, c.sub, c.force); err != nil {
+		return err
+	}
+	fmt.Printf("deleted subscription: %s %s\n", ref.FullName, c.sub)
+	return nil
+}
+
+// helpers
+
+func printSubscriptionTable(subs []pulsarClient.SubscriptionInfo) {
+	maxNameLen := len("SUBSCRIPTION")
+	for _, s := range subs {
+		if l := len(s.Name); l > maxNameLen {
+			maxNameLen = l
+		}
+	}
+	fmt.Printf("%-*s | %-9s | %12s | %9s | %10s | %10s\n",
+		maxNameLen, "SUBSCRIPTION", "TYPE", "BACKLOG", "CONSUMERS", "UNACKED", "RATE OUT")
+	fmt.Printf("%s-+-%s-+-%s-+-%s-+-%s-+-%s\n",
+		strings.Repeat("-", maxNameLen),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 10),
+	)
+	for _, s := range subs {
+		fmt.Printf("%-*s | %-9s | %12s | %9d | %10s | %10.2f\n",
+			maxNameLen,
+			s.Name,
+			s.Type,
+			formatIntWithSep(s.Backlog),
+			s.Consumers,
+			formatIntWithSep(s.Unacked),
+			s.RateOut,
+		)
+	}
+}
+
+// parseResetTime понимает RFC3339 или длительность «назад» (1h, 30m).
+func parseResetTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return time.Time{}, usageErrorf("invalid --time %q: expected RFC3339 or duration like 1h", s)
+	}
+	return now.Add(-d), nil
+}
+
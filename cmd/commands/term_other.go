@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package commands
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoTerminal = errors.New("terminal control is not supported on this platform")
+
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errNoTerminal
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	return 0, 0, errNoTerminal
+}
@@ -0,0 +1,492 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	pulsarClient "puls/cmd/client"
+	pulsarContext "puls/cmd/ctx"
+)
+
+// ключи сортировки для --sort и горячих клавиш
+const (
+	topSortBacklog   = "backlog"
+	topSortDelta     = "delta"
+	topSortRateIn    = "rate-in"
+	topSortRateOut   = "rate-out"
+	topSortConsumers = "consumers"
+	topSortName      = "name"
+)
+
+var topSortKeys = []string{topSortBacklog, topSortDelta, topSortRateIn, topSortRateOut, topSortConsumers, topSortName}
+
+// клавиша → ключ сортировки
+var topSortHotkeys = map[byte]string{
+	'b': topSortBacklog,
+	'd': topSortDelta,
+	'i': topSortRateIn,
+	'o': topSortRateOut,
+	'c': topSortConsumers,
+	'n': topSortName,
+}
+
+const (
+	ansiClear     = "\x1b[H\x1b[2J"
+	ansiAltScreen = "\x1b[?1049h\x1b[?25l"
+	ansiMainScr   = "\x1b[?25h\x1b[?1049l"
+	ansiRed       = "\x1b[31m"
+	ansiBold      = "\x1b[1m"
+	ansiReset     = "\x1b[0m"
+)
+
+type topRow struct {
+	Ref       pulsarClient.TopicRef
+	Kind      string
+	Backlog   int64
+	Delta     int64
+	RateIn    float64
+	RateOut   float64
+	Consumers int
+	New       bool // появился в этом интервале, дельты ещё нет
+}
+
+type topSample struct {
+	At     time.Time
+	Rows   []topRow
+	Errors int
+	Err    error // ошибка листинга — весь сэмпл пропущен
+}
+
+type topView struct {
+	sortKey string
+	reverse bool
+	tty     bool
+	width   int
+	height  int
+}
+
+// topFlags — флаги top.
+type topFlags struct {
+	g                                             *globalFlags
+	interval                                      time.Duration
+	sortKey                                       string
+	parallel, limit, iterations                   int
+	includeInternal, withPartitioned, full, plain bool
+	ff                                            *filterFlags
+}
+
+func bindTopFlags(fs *flag.FlagSet) *topFlags {
+	o := &topFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.DurationVar(&o.interval, "interval", 5*time.Second, "refresh interval")
+	fs.StringVar(&o.sortKey, "sort", topSortBacklog, "sort by: "+strings.Join(topSortKeys, ", "))
+	fs.IntVar(&o.parallel, "parallel", 16, "max parallel stats requests")
+	fs.IntVar(&o.limit, "limit", 0, "max rows (default: fit the terminal, all rows when not a TTY)")
+	fs.IntVar(&o.iterations, "iterations", 0, "stop after N refreshes, failed ones included; exit 1 if the last one failed (0 = until interrupted)")
+	fs.BoolVar(&o.includeInternal, "include-internal", false, "include system/internal topics")
+	fs.BoolVar(&o.withPartitioned, "with-partitioned", true, "with partitioned topics")
+	fs.BoolVar(&o.full, "full", false, "show topics with backlog=0 too")
+	fs.BoolVar(&o.plain, "plain", false, "plain repeated output even on a TTY")
+	o.ff = bindFilterFlags(fs, true)
+	return o
+}
+
+func CmdTop(args []string) error {
+	fs := newFlagSet("top")
+	o := bindTopFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains(topSortKeys, o.sortKey) {
+		return usageErrorf("unknown --sort %q (supported: %s)", o.sortKey, strings.Join(topSortKeys, ", "))
+	}
+	if o.interval < time.Second {
+		return usageErrorf("--interval must be at least 1s")
+	}
+
+	cx, err := o.g.loadContext()
+	if err != nil {
+		return err
+	}
+	filter, err := o.ff.build(cx)
+	if err != nil {
+		return err
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return err
+	}
+	o.withPartitioned = o.withPartitioned && filter.WantKind(pulsarClient.KindPartitioned)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	view := &topView{sortKey: o.sortKey, tty: !o.plain && isTerminal(os.Stdout)}
+	var keys <-chan byte
+	if view.tty {
+		fmt.Print(ansiAltScreen)
+		defer fmt.Print(ansiMainScr)
+		if isTerminal(os.Stdin) {
+			if restore, err := makeRaw(int(os.Stdin.Fd())); err == nil {
+				defer restore()
+				keys = readKeys(os.Stdin)
+			}
+		}
+	}
+
+	fetch := func() topSample {
+		return fetchTopSample(ctx, h, cx, filter, o.includeInternal, o.withPartitioned, o.parallel)
+	}
+
+	var prev, cur *topSample
+	samples := make(chan topSample, 1)
+	go func() { samples <- fetch() }()
+	inflight := true
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	redraw := func() {
+		if cur == nil {
+			return
+		}
+		rows := filterTopRows(cur.Rows, func(r topRow) bool {
+			if !filter.MatchBacklog(r.Backlog) {
+				return false
+			}
+			return o.full || filter.MinBacklog != nil || r.Backlog > 0 || r.Delta != 0
+		})
+		sortTopRows(rows, view.sortKey, view.reverse)
+		if view.tty {
+			view.width, view.height, _ = terminalSize(int(os.Stdout.Fd()))
+		}
+		renderTop(os.Stdout, o.g.label(cx), cx, o.interval, cur, rows, view, o.limit)
+	}
+
+	done := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case s := <-samples:
+			inflight = false
+			// неудачные обновления тоже считаются в --iterations, иначе
+			// при недоступном брокере top в cron не завершится никогда
+			done++
+			last := o.iterations > 0 && done >= o.iterations
+			if s.Err != nil {
+				if !view.tty {
+					fmt.Fprintf(os.Stderr, "warn: %v\n", s.Err)
+				} else {
+					// на экране остаются прошлые данные с пометкой об ошибке
+					if cur == nil {
+						cur = &topSample{At: s.At}
+					}
+					cur.Err = s.Err
+					redraw()
+				}
+				if last {
+					return fmt.Errorf("last refresh failed: %w", s.Err)
+				}
+				break
+			}
+			computeTopDeltas(prev, &s)
+			prev, cur = &s, &s
+			redraw()
+			if last {
+				return nil
+			}
+
+		case <-ticker.C:
+			if !inflight {
+				inflight = true
+				go func() { samples <- fetch() }()
+			}
+
+		case k := <-keys:
+			switch {
+			case k == 'q' || k == 'Q':
+				return nil
+			case k == 'r':
+				view.reverse = !view.reverse
+			case topSortHotkeys[k] != "":
+				view.sortKey = topSortHotkeys[k]
+			default:
+				continue
+			}
+			redraw()
+		}
+	}
+}
+
+func fetchTopSample(
+	ctx context.Context,
+	h *pulsarClient.HttpClient,
+	cx *pulsarContext.Context,
+	filter *pulsarClient.TopicFilter,
+	includeInternal bool,
+	withPartitioned bool,
+	parallel int,
+) topSample {
+	s := topSample{At: time.Now()}
+
+	if filter.WantKind(pulsarClient.KindNonPartitioned) {
+		nonParts, err := pulsarClient.ListNonPartitionedTopics(ctx, h, cx.Tenant, cx.Namespace, includeInternal)
+		if err != nil {
+			s.Err = err
+			return s
+		}
+		nonParts = filter.FilterTopics(nonParts)
+		infos := pulsarClient.FetchNonPartitionedBacklogsParallel(ctx, h, nonParts, parallel)
+		s.addRows(infos, pulsarClient.KindNonPartitioned)
+	}
+
+	if withPartitioned {
+		parts, err := pulsarClient.ListPartitionedTopics(ctx, h, cx.Tenant, cx.Namespace, includeInternal)
+		if err != nil {
+			s.Err = err
+			return s
+		}
+		parts = filter.FilterTopics(parts)
+		infos := pulsarClient.FetchPartitionedBacklogsParallel(ctx, h, parts, parallel)
+		s.addRows(infos, pulsarClient.KindPartitioned)
+	}
+	return s
+}
+
+func (s *topSample) addRows(infos []pulsarClient.TopicBacklog, kind string) {
+	for _, info := range infos {
+		if info.Err != nil {
+			s.Errors++
+			continue
+		}
+		s.Rows = append(s.Rows, topRow{
+			Ref:       info.Ref,
+			Kind:      kind,
+			Backlog:   info.Backlog,
+			RateIn:    info.Stats.MsgRateIn,
+			RateOut:   info.Stats.MsgRateOut,
+			Consumers: info.Stats.ConsumerCount(),
+		})
+	}
+}
+
+// computeTopDeltas — изменение бэклога относительно предыдущего сэмпла.
+func computeTopDeltas(prev *topSample, cur *topSample) {
+	before := map[string]int64{}
+	if prev != nil {
+		for _, r := range prev.Rows {
+			before[r.Ref.FullName] = r.Backlog
+		}
+	}
+	for i := range cur.Rows {
+		r := &cur.Rows[i]
+		b, ok := before[r.Ref.FullName]
+		if !ok {
+			r.New = prev != nil
+			continue
+		}
+		r.Delta = r.Backlog - b
+	}
+}
+
+func filterTopRows(rows []topRow, keep func(topRow) bool) []topRow {
+	out := make([]topRow, 0, len(rows))
+	for _, r := range rows {
+		if keep(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// sortTopRows сортирует по убыванию выбранной метрики (имя — по возрастанию);
+// при равенстве — по имени, чтобы строки не прыгали между обновлениями.
+func sortTopRows(rows []topRow, key string, reverse bool) {
+	less := func(a, b topRow) int {
+		switch key {
+		case topSortBacklog:
+			return cmpDesc(a.Backlog, b.Backlog)
+		case topSortDelta:
+			return cmpDesc(a.Delta, b.Delta)
+		case topSortRateIn:
+			return cmpDesc(a.RateIn, b.RateIn)
+		case topSortRateOut:
+			return cmpDesc(a.RateOut, b.RateOut)
+		case topSortConsumers:
+			return cmpDesc(a.Consumers, b.Consumers)
+		}
+		return 0
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := less(rows[i], rows[j])
+		if c == 0 {
+			c = strings.Compare(rows[i].Ref.FullName, rows[j].Ref.FullName)
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func cmpDesc[T int | int64 | float64](a, b T) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+func renderTop(
+	w io.Writer,
+	label string,
+	cx *pulsarContext.Context,
+	interval time.Duration,
+	s *topSample,
+	rows []topRow,
+	view *topView,
+	limit int,
+) {
+	var b strings.Builder
+	if view.tty {
+		b.WriteString(ansiClear)
+	}
+
+	// по имени — по алфавиту, остальные метрики — по убыванию
+	order := "desc"
+	if (view.sortKey == topSortName) != view.reverse {
+		order = "asc"
+	}
+	fmt.Fprintf(&b, "puls top  context=%s  %s/%s  prefix=%q  every %s  %s\n",
+		label, cx.Tenant, cx.Namespace, cx.Prefix, interval, s.At.Format("15:04:05"))
+
+	var totalBacklog, totalDelta int64
+	growing := 0
+	for _, r := range rows {
+		totalBacklog += r.Backlog
+		totalDelta += r.Delta
+		if r.Delta > 0 {
+			growing++
+		}
+	}
+	fmt.Fprintf(&b, "topics %d  growing %d  backlog %s (%s)  sort %s %s",
+		len(rows), growing, formatIntWithSep(totalBacklog), formatDelta(totalDelta, false), view.sortKey, order)
+	if s.Errors > 0 {
+		fmt.Fprintf(&b, "  stats errors %d", s.Errors)
+	}
+	b.WriteString("\n")
+	if s.Err != nil {
+		fmt.Fprintf(&b, "last refresh failed: %v\n", s.Err)
+	}
+	if view.tty {
+		b.WriteString("keys: b backlog  d delta  i rate-in  o rate-out  c consumers  n name  r reverse  q quit\n")
+	}
+	b.WriteString("\n")
+
+	// строк влезает: высота минус шапка (4) и заголовок таблицы (2)
+	if limit <= 0 && view.tty && view.height > 0 {
+		limit = max(view.height-6, 1)
+	}
+	hidden := 0
+	if limit > 0 && len(rows) > limit {
+		hidden = len(rows) - limit
+		rows = rows[:limit]
+	}
+
+	nameLen := len("TOPIC")
+	for _, r := range rows {
+		nameLen = max(nameLen, utf8.RuneCountInString(r.Ref.Name))
+	}
+	if view.tty && view.width > 0 {
+		// остальные колонки занимают ~70 символов
+		nameLen = min(nameLen, max(view.width-70, 20))
+	}
+
+	header := fmt.Sprintf("%-*s | %14s | %12s | %10s | %10s | %5s | %s",
+		nameLen, "TOPIC", "BACKLOG", "DELTA", "IN/s", "OUT/s", "CONS", "KIND")
+	if view.tty {
+		header = ansiBold + header + ansiReset
+	}
+	b.WriteString(header + "\n")
+	fmt.Fprintf(&b, "%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s\n",
+		strings.Repeat("-", nameLen), strings.Repeat("-", 14), strings.Repeat("-", 12),
+		strings.Repeat("-", 10), strings.Repeat("-", 10), strings.Repeat("-", 5), strings.Repeat("-", 6))
+
+	for _, r := range rows {
+		kindShort := "part"
+		if r.Kind == pulsarClient.KindNonPartitioned {
+			kindShort = "nonpar"
+		}
+		name := truncateName(r.Ref.Name, nameLen)
+		line := fmt.Sprintf("%-*s | %14s | %12s | %10.1f | %10.1f | %5d | %s",
+			nameLen, name,
+			formatIntWithSep(r.Backlog),
+			formatDelta(r.Delta, r.New),
+			r.RateIn, r.RateOut, r.Consumers, kindShort,
+		)
+		if view.tty && r.Delta > 0 {
+			line = ansiRed + line + ansiReset
+		}
+		b.WriteString(line + "\n")
+	}
+	if hidden > 0 {
+		fmt.Fprintf(&b, "... %d more (use --limit)\n", hidden)
+	}
+	if !view.tty {
+		b.WriteString("\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+func formatDelta(d int64, isNew bool) string {
+	switch {
+	case isNew:
+		return "new"
+	case d > 0:
+		return "+" + formatIntWithSep(d)
+	}
+	return formatIntWithSep(d)
+}
+
+// helpers
+
+// readKeys отдаёт нажатые клавиши; горутина живёт до конца процесса.
+func readKeys(r io.Reader) <-chan byte {
+	ch := make(chan byte)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, c := range buf[:n] {
+				ch <- c
+			}
+		}
+	}()
+	return ch
+}
+
+// truncateName обрезает имя до n символов (рун, не байт), помечая обрезку "~".
+func truncateName(name string, n int) string {
+	if utf8.RuneCountInString(name) <= n {
+		return name
+	}
+	r := []rune(name)
+	return string(r[:n-1]) + "~"
+}
@@ -0,0 +1,23 @@
+package commands
+
+import "testing"
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"orders", 10, "orders"},
+		{"orders", 6, "orders"},
+		{"orders-dlq", 6, "order~"},
+		{"заказы-dlq", 6, "заказ~"},
+		{"заказы", 6, "заказы"},
+		{"日本語トピック", 4, "日本語~"},
+	}
+	for _, tt := range tests {
+		if got := truncateName(tt.in, tt.n); got != tt.want {
+			t.Errorf("truncateName(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
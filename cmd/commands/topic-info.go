@@ -2,10 +2,12 @@ package commands
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"encoding/json"
 	"flag"
-	pulsarConfig "puls/cmd/config"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	pulsarClient "puls/cmd/client"
 )
 
@@ -16,62 +18,294 @@ const (
 	TopicPartitioned
 )
 
+func (k TopicKind) String() string {
+	if k == TopicPartitioned {
+		return "partitioned"
+	}
+	return "non-partitioned"
+}
+
+// topicReport — всё, что показывает topic-info (и отдаёт в --output json).
+type topicReport struct {
+	Topic        string                             `json:"topic"`
+	Domain       string                             `json:"domain"`
+	Kind         string                             `json:"kind"`
+	Partitions   int                                `json:"partitions"`
+	Backlog      int64                              `json:"backlog"`
+	Empty        bool                               `json:"empty"`
+	Stats        *pulsarClient.TopicStats           `json:"stats"`
+	PerPartition map[string]pulsarClient.TopicStats `json:"per_partition,omitempty"`
+	Context      string                             `json:"context,omitempty"` // при опросе нескольких контекстов
+}
+
+// topicInfoFlags — флаги topic-info.
+type topicInfoFlags struct {
+	g                *globalFlags
+	topicArg, output string
+	cf               *contextFlags
+}
+
+func bindTopicInfoFlags(fs *flag.FlagSet) *topicInfoFlags {
+	o := &topicInfoFlags{}
+	o.g = addGlobalFlags(fs)
+	fs.StringVar(&o.topicArg, "topic", "", "topic name (persistent://tenant/ns/name, non-persistent://tenant/ns/name or just name)")
+	fs.StringVar(&o.output, "output", outputTable, "output format: table, json")
+	o.cf = bindContextFlags(fs, o.g)
+	return o
+}
+
 func CmdTopicInfo(args []string) error {
-	fs := flag.NewFlagSet("topic-info", flag.ContinueOnError)
-	var ctxName, topicArg string
-	fs.StringVar(&ctxName, "context", "", "context name (optional)")
-	fs.StringVar(&topicArg, "topic", "", "topic name (persistent://tenant/ns/name or just name)")
-	if err := fs.Parse(args); err != nil {
+	fs := newFlagSet("topic-info")
+	o := bindTopicInfoFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
 		return err
 	}
-	if topicArg == "" {
-		return errors.New("usage: puls topic-info --topic <name or persistent://tenant/ns/name>")
+	if o.topicArg == "" {
+		return usageErrorf("usage: puls topic-info --topic <name or persistent://tenant/ns/name>")
+	}
+	if o.output != outputTable && o.output != outputJSON {
+		return usageErrorf("unknown output format %q (supported: %s, %s)", o.output, outputTable, outputJSON)
 	}
 
-	cfg, err := pulsarConfig.LoadConfig()
+	names, err := o.cf.names()
 	if err != nil {
 		return err
 	}
-	cx, err := pulsarConfig.MustContext(cfg, ctxName)
+	if names != nil {
+		return topicInfoContexts(o.cf, names, o.topicArg, o.output)
+	}
+
+	rep, err := loadTopicReport(o.g, o.topicArg)
 	if err != nil {
 		return err
 	}
-	h := pulsarClient.NewHTTP(cx)
-	ctx := context.Background()
 
+	if o.output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+	printTopicReport(rep)
+	return nil
+}
+
+// topicInfoContexts — один топик в нескольких контекстах: сравнительная
+// таблица (или массив отчётов в json). Топик ищется в каждом контексте
+// по его tenant/namespace, если имя короткое.
+func topicInfoContexts(cf *contextFlags, names []string, topicArg, output string) error {
+	results := fanOut(cf, names, func(g *globalFlags) (*topicReport, error) {
+		return loadTopicReport(g, topicArg)
+	})
+	reports := []*topicReport{}
+	for _, r := range results {
+		if r.Err == nil {
+			r.Value.Context = r.Context
+			reports = append(reports, r.Value)
+		}
+	}
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else if len(reports) > 0 {
+		printTopicComparison(reports)
+	}
+	return reportContextErrors(results)
+}
+
+func loadTopicReport(g *globalFlags, topicArg string) (*topicReport, error) {
+	cx, err := g.loadContext()
+	if err != nil {
+		return nil, err
+	}
+	h, err := pulsarClient.NewHTTP(cx)
+	if err != nil {
+		return nil, err
+	}
 	ref, err := pulsarClient.ParseTopicArg(topicArg, cx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return buildTopicReport(context.Background(), h, ref)
+}
 
-	// пробуем сначала как partitioned, потом как non-partitioned
+func buildTopicReport(ctx context.Context, h *pulsarClient.HttpClient, ref pulsarClient.TopicRef) (*topicReport, error) {
+	n, err := pulsarClient.GetPartitionCount(ctx, h, ref)
+	if err != nil {
+		return nil, err
+	}
 	kind := TopicNonPartitioned
-	empty := false
-	var backlog int64
-
-	if _, err := pulsarClient.GetPartitionedStats(ctx, h, ref); err == nil {
+	if n > 0 {
 		kind = TopicPartitioned
-		e, b, err := pulsarClient.IsEmptyPartitioned(ctx, h, ref)
+	}
+	rep := &topicReport{
+		Topic:      ref.FullName,
+		Domain:     ref.Domain,
+		Kind:       kind.String(),
+		Partitions: n,
+	}
+
+	if kind == TopicPartitioned {
+		ps, err := pulsarClient.GetPartitionedStats(ctx, h, ref)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		empty, backlog = e, b
+		rep.Stats = &ps.TopicStats
+		rep.PerPartition = ps.Partitions
+		rep.Backlog = ps.TotalBacklog()
 	} else {
-		e, b, err := pulsarClient.IsEmptyNonPartitioned(ctx, h, ref)
+		s, err := pulsarClient.GetNonPartitionedStats(ctx, h, ref)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		empty, backlog = e, b
+		rep.Stats = s
+		rep.Backlog = s.TotalBacklog()
 	}
+	rep.Empty = rep.Backlog == 0
+	return rep, nil
+}
 
-	kindStr := "non-partitioned"
-	if kind == TopicPartitioned {
-		kindStr = "partitioned"
+func printTopicReport(rep *topicReport) {
+	s := rep.Stats
+
+	fmt.Printf("topic:   %s\n", rep.Topic)
+	fmt.Printf("kind:    %s\n", rep.Kind)
+	if rep.Domain == pulsarClient.DomainNonPersistent {
+		fmt.Printf("domain:  %s (no storage, backlog is always 0)\n", rep.Domain)
+	}
+	if rep.Partitions > 0 {
+		fmt.Printf("partitions: %d\n", rep.Partitions)
 	}
+	fmt.Printf("backlog: %d\n", rep.Backlog)
+	fmt.Printf("empty(backlog=0): %v\n", rep.Empty)
 
-	fmt.Printf("topic:   %s\n", ref.FullName)
-	fmt.Printf("kind:    %s\n", kindStr)
-	fmt.Printf("backlog: %d\n", backlog)
-	fmt.Printf("empty(backlog=0): %v\n", empty)
-	return nil
+	fmt.Println()
+	fmt.Printf("rate in:    %.2f msg/s, %s/s\n", s.MsgRateIn, formatBytes(int64(s.MsgThroughputIn)))
+	fmt.Printf("rate out:   %.2f msg/s, %s/s\n", s.MsgRateOut, formatBytes(int64(s.MsgThroughputOut)))
+	fmt.Printf("storage:    %s (backlog %s, offloaded %s)\n",
+		formatBytes(s.StorageSize), formatBytes(s.BacklogSize), formatBytes(s.OffloadedStorageSize))
+	fmt.Printf("msg in/out: %s / %s\n", formatIntWithSep(s.MsgInCounter), formatIntWithSep(s.MsgOutCounter))
+
+	fmt.Println()
+	if len(s.Publishers) == 0 {
+		fmt.Println("producers: none")
+	} else {
+		fmt.Printf("producers (%d):\n", len(s.Publishers))
+		for _, p := range s.Publishers {
+			fmt.Printf("  %s  %s  %.2f msg/s  %s/s  %s\n",
+				p.ProducerName, p.Address, p.MsgRateIn, formatBytes(int64(p.MsgThroughputIn)), p.ClientVersion)
+		}
+	}
+
+	fmt.Println()
+	subs := pulsarClient.SubscriptionInfos(s)
+	if len(subs) == 0 {
+		fmt.Println("subscriptions: none")
+	} else {
+		fmt.Printf("subscriptions (%d):\n", len(subs))
+		printSubscriptionTable(subs)
+		for _, sub := range subs {
+			for _, c := range sub.Stats.Consumers {
+				fmt.Printf("  %s <- %s  %s  unacked=%d  %.2f msg/s\n",
+					sub.Name, c.ConsumerName, c.Address, c.UnackedMessages, c.MsgRateOut)
+			}
+		}
+	}
+
+	if len(rep.PerPartition) > 0 {
+		fmt.Println()
+		fmt.Println("partitions:")
+		printPartitionTable(rep.PerPartition)
+	}
+}
+
+// printTopicComparison — по строке на контекст.
+func printTopicComparison(reports []*topicReport) {
+	ctxLen, topicLen := len("CONTEXT"), len("TOPIC")
+	for _, r := range reports {
+		ctxLen = max(ctxLen, len(r.Context))
+		topicLen = max(topicLen, len(r.Topic))
+	}
+	fmt.Printf("%-*s | %-*s | %6s | %12s | %10s | %10s | %10s | %9s | %9s | %4s\n",
+		ctxLen, "CONTEXT", topicLen, "TOPIC", "KIND", "BACKLOG", "RATE IN", "RATE OUT", "STORAGE", "PRODUCERS", "CONSUMERS", "SUBS")
+	fmt.Printf("%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s-+-%s\n",
+		strings.Repeat("-", ctxLen),
+		strings.Repeat("-", topicLen),
+		strings.Repeat("-", 6),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 4),
+	)
+	for _, r := range reports {
+		s := r.Stats
+		kind := "nonpar"
+		if r.Partitions > 0 {
+			kind = fmt.Sprintf("p=%d", r.Partitions)
+		}
+		fmt.Printf("%-*s | %-*s | %6s | %12s | %10.2f | %10.2f | %10s | %9d | %9d | %4d\n",
+			ctxLen, r.Context,
+			topicLen, r.Topic,
+			kind,
+			formatIntWithSep(r.Backlog),
+			s.MsgRateIn,
+			s.MsgRateOut,
+			formatBytes(s.StorageSize),
+			len(s.Publishers),
+			s.ConsumerCount(),
+			len(s.Subscriptions),
+		)
+	}
+}
+
+func printPartitionTable(parts map[string]pulsarClient.TopicStats) {
+	names := make([]string, 0, len(parts))
+	maxNameLen := len("PARTITION")
+	for name := range parts {
+		names = append(names, name)
+		if l := len(name); l > maxNameLen {
+			maxNameLen = l
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%-*s | %12s | %10s | %10s | %10s\n",
+		maxNameLen, "PARTITION", "BACKLOG", "RATE IN", "RATE OUT", "STORAGE")
+	fmt.Printf("%s-+-%s-+-%s-+-%s-+-%s\n",
+		strings.Repeat("-", maxNameLen),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 10),
+	)
+	for _, name := range names {
+		p := parts[name]
+		fmt.Printf("%-*s | %12s | %10.2f | %10.2f | %10s\n",
+			maxNameLen,
+			name,
+			formatIntWithSep(p.TotalBacklog()),
+			p.MsgRateIn,
+			p.MsgRateOut,
+			formatBytes(p.StorageSize),
+		)
+	}
+}
+
+// formatBytes — 1536 → "1.5 KiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+
+	ctx "puls/cmd/ctx"
+)
+
+// Переменные окружения. Приоритет везде одинаковый: флаг > env > контекст.
+const (
+	EnvConfig    = "PULS_CONFIG"    // путь к config.json (флаг --config важнее)
+	EnvContext   = "PULS_CONTEXT"   // имя контекста вместо current (флаг --context важнее)
+	EnvAdminURL  = "PULS_ADMIN_URL" // переопределяют поля выбранного контекста
+	EnvToken     = "PULS_TOKEN"
+	EnvTenant    = "PULS_TENANT"
+	EnvNamespace = "PULS_NAMESPACE"
+	EnvPrefix    = "PULS_PREFIX"
+
+	// имя контекста, собранного только из env (когда контекст не выбран)
+	envContextName = "env"
+)
+
+var configPathOverride string
+
+// SetConfigPath задаёт путь к конфигу из глобального флага --config.
+func SetConfigPath(p string) {
+	configPathOverride = p
+}
+
+// applyEnvOverrides переносит PULS_* поверх полей контекста.
+func applyEnvOverrides(c *ctx.Context) {
+	if v := os.Getenv(EnvAdminURL); v != "" {
+		c.AdminURL = v
+	}
+	if v := os.Getenv(EnvToken); v != "" {
+		c.Token = v
+		c.AuthType = "token"
+	}
+	if v := os.Getenv(EnvTenant); v != "" {
+		c.Tenant = v
+	}
+	if v := os.Getenv(EnvNamespace); v != "" {
+		c.Namespace = v
+	}
+	if v := os.Getenv(EnvPrefix); v != "" {
+		c.Prefix = v
+	}
+}
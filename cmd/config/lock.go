@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockTimeout      = 10 * time.Second
+	lockPollInterval = 50 * time.Millisecond
+)
+
+var errLocked = errors.New("config is locked")
+
+// LockConfig берёт эксклюзивную advisory-блокировку на конфиг
+// (файл <config>.lock). Держать её нужно на всё время
+// load → modify → save, иначе параллельные puls потеряют изменения друг друга.
+func LockConfig() (unlock func(), err error) {
+	p, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("%s is locked by another puls process (waited %s)", p, lockTimeout)
+			}
+			return nil, fmt.Errorf("lock %s: %w", p, err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
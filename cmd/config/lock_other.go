@@ -0,0 +1,21 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package config
+
+import "os"
+
+// Здесь нет flock: блокировка — no-op, от порчи файла защищает только
+// атомарный os.Rename при записи.
+func tryLockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) {}
+
+// syncDir — best-effort fsync каталога; где его нет, ошибка игнорируется.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
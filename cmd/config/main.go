@@ -14,7 +14,14 @@ type Config struct {
 	Contexts map[string]*ctx.Context `json:"contexts"`
 }
 
+// configPath: --config > PULS_CONFIG > ~/.config/puls/config.json
 func configPath() (string, error) {
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
+	if p := os.Getenv(EnvConfig); p != "" {
+		return p, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -22,6 +29,15 @@ func configPath() (string, error) {
 	return filepath.Join(home, ".config", "puls", "config.json"), nil
 }
 
+// Dir — каталог конфига; рядом хранятся snapshots/ и прочие файлы puls.
+func Dir() (string, error) {
+	p, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(p), nil
+}
+
 func LoadConfig() (*Config, error) {
 	p, err := configPath()
 	if err != nil {
@@ -44,30 +60,97 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
+// SaveConfig пишет конфиг атомарно: временный файл рядом → fsync → rename.
+// Предыдущая версия сохраняется в <config>.bak. Для read-modify-write
+// вызывающий должен держать LockConfig.
 func SaveConfig(cfg *Config) error {
 	p, err := configPath()
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
 	b, _ := json.MarshalIndent(cfg, "", "  ")
-	return os.WriteFile(p, b, 0o600)
+
+	if prev, err := os.ReadFile(p); err == nil {
+		if err := writeFileAtomic(p+".bak", prev); err != nil {
+			return fmt.Errorf("backup config: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return writeFileAtomic(p, b)
 }
 
+func writeFileAtomic(p string, b []byte) error {
+	dir := filepath.Dir(p)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// при любой ошибке ниже временный файл не должен остаться
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if err := tmp.Chmod(0o600); err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, p); err != nil {
+		return err
+	}
+	ok = true
+	syncDir(dir)
+	return nil
+}
+
+// MustContext возвращает копию выбранного контекста с раскрытыми
+// ссылками на секреты; сам cfg не меняется, поэтому SaveConfig
+// никогда не запишет раскрытые значения на диск.
+//
+// Контекст выбирается так: nameOpt (--context) > PULS_CONTEXT > current.
+// Поля контекста перекрываются PULS_ADMIN_URL/PULS_TOKEN/PULS_TENANT/
+// PULS_NAMESPACE/PULS_PREFIX; если контекст не выбран, но задан
+// PULS_ADMIN_URL, контекст собирается целиком из окружения (удобно в CI).
 func MustContext(cfg *Config, nameOpt string) (*ctx.Context, error) {
 	name := nameOpt
 	if name == "" {
-		name = cfg.Current
+		name = os.Getenv(EnvContext)
 	}
 	if name == "" {
-		return nil, errors.New("context is not selected; run: puls context use <name>")
+		name = cfg.Current
 	}
-	ctx := cfg.Contexts[name]
-	if ctx == nil {
-		return nil, fmt.Errorf("context %q not found", name)
+	var ctx ctx.Context
+	switch {
+	case name != "":
+		stored := cfg.Contexts[name]
+		if stored == nil {
+			return nil, fmt.Errorf("context %q not found", name)
+		}
+		ctx = *stored
+	case os.Getenv(EnvAdminURL) != "":
+		name = envContextName
+		ctx.Name = envContextName
+	default:
+		return nil, errors.New("context is not selected; run: puls context use <name>")
 	}
+	applyEnvOverrides(&ctx)
 	if ctx.AdminURL == "" {
 		return nil, fmt.Errorf(
 			"context %q is missing admin_url; set with: puls context set --name %s --url http://host:8080/admin/v2",
@@ -85,5 +168,8 @@ func MustContext(cfg *Config, nameOpt string) (*ctx.Context, error) {
 	if ctx.HTTPTimeoutSec <= 0 {
 		ctx.HTTPTimeoutSec = 20
 	}
-	return ctx, nil
-}
\ No newline at end of file
+	if err := ResolveSecrets(&ctx); err != nil {
+		return nil, err
+	}
+	return &ctx, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	ctx "puls/cmd/ctx"
+)
+
+const (
+	secretEnvPrefix  = "env:"
+	secretFilePrefix = "file:"
+	secretExecPrefix = "exec:"
+
+	redacted = "<redacted>"
+
+	secretExecTimeout = 30 * time.Second
+)
+
+// secretFields — поля контекста, которые могут быть ссылками на секреты
+// (env:NAME, file:/path, exec:command) и скрываются в `context get`.
+func secretFields(c *ctx.Context) map[string]*string {
+	return map[string]*string{
+		"token":                &c.Token,
+		"oauth2_client_secret": &c.OAuth2ClientSecret,
+		"basic_password":       &c.BasicPassword,
+	}
+}
+
+// SecretFlags — флаги с секретами (context set); их значения скрываются
+// в командной строке, которая пишется в аудит.
+var SecretFlags = []string{"token", "oauth2-client-secret", "basic-password"}
+
+// IsSecretRef — значение является ссылкой, а не самим секретом.
+func IsSecretRef(v string) bool {
+	return strings.HasPrefix(v, secretEnvPrefix) ||
+		strings.HasPrefix(v, secretFilePrefix) ||
+		strings.HasPrefix(v, secretExecPrefix)
+}
+
+// ResolveSecret раскрывает ссылку на секрет; обычное значение возвращается как есть.
+func ResolveSecret(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, secretEnvPrefix):
+		name := strings.TrimPrefix(v, secretEnvPrefix)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return val, nil
+
+	case strings.HasPrefix(v, secretFilePrefix):
+		p := strings.TrimPrefix(v, secretFilePrefix)
+		if strings.HasPrefix(p, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				p = home + p[1:]
+			}
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+
+	case strings.HasPrefix(v, secretExecPrefix):
+		command := strings.TrimPrefix(v, secretExecPrefix)
+		c, cancel := context.WithTimeout(context.Background(), secretExecTimeout)
+		defer cancel()
+		out, err := RunShell(c, command)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(out), nil
+	}
+	return v, nil
+}
+
+// RunShell выполняет команду через системный shell и возвращает stdout;
+// общий для exec:-ссылок и auth_type token_exec.
+func RunShell(c context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(c, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(c, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return "", fmt.Errorf("%q: %w (%s)", command, err, msg)
+		}
+		return "", fmt.Errorf("%q: %w", command, err)
+	}
+	return stdout.String(), nil
+}
+
+// ResolveSecrets раскрывает ссылки во всех секретных полях контекста.
+func ResolveSecrets(c *ctx.Context) error {
+	for field, p := range secretFields(c) {
+		if *p == "" {
+			continue
+		}
+		v, err := ResolveSecret(*p)
+		if err != nil {
+			return fmt.Errorf("context %q: resolve %s: %w", c.Name, field, err)
+		}
+		*p = v
+	}
+	return nil
+}
+
+// RedactSecrets скрывает секреты, заданные литералом; ссылки остаются видны.
+func RedactSecrets(c *ctx.Context) {
+	for _, p := range secretFields(c) {
+		*p = RedactSecret(*p)
+	}
+}
+
+// RedactSecret — то же для одного значения.
+func RedactSecret(v string) string {
+	if v != "" && !IsSecretRef(v) {
+		return redacted
+	}
+	return v
+}
@@ -8,4 +8,35 @@ type Context struct {
 	Namespace      string `json:"namespace"`        // core-dev
 	Prefix         string `json:"prefix"`           // например "ahuzhamberdiev|"
 	HTTPTimeoutSec int    `json:"http_timeout_sec"` // таймаут HTTP-запросов
+
+	Include []string `json:"include,omitempty"` // шаблоны топиков: prefix:, glob:, regex: (см. client.ParsePattern)
+	Exclude []string `json:"exclude,omitempty"` // например "glob:*-dlq", "glob:*-retry"
+
+	Protected []string `json:"protected,omitempty"` // шаблоны топиков, которые деструктивные команды не трогают
+	ReadOnly  bool     `json:"readonly,omitempty"`  // запретить деструктивные команды в этом контексте
+	AuditLog  string   `json:"audit_log,omitempty"` // JSONL-журнал изменяющих запросов, пусто = audit.jsonl рядом с конфигом
+
+	RetryMaxAttempts   int  `json:"retry_max_attempts,omitempty"`   // всего попыток на запрос, 0 = дефолт (3)
+	RetryBackoffMs     int  `json:"retry_backoff_ms,omitempty"`     // начальная пауза между попытками, 0 = дефолт (200)
+	RetryMaxBackoffMs  int  `json:"retry_max_backoff_ms,omitempty"` // потолок паузы, 0 = дефолт (5000)
+	RetryNonIdempotent bool `json:"retry_non_idempotent,omitempty"` // ретраить и POST
+
+	TLSCAFile             string `json:"tls_ca_file,omitempty"`              // PEM с CA для проверки брокера
+	TLSCertFile           string `json:"tls_cert_file,omitempty"`            // клиентский сертификат (mTLS)
+	TLSKeyFile            string `json:"tls_key_file,omitempty"`             // ключ клиентского сертификата
+	TLSInsecureSkipVerify bool   `json:"tls_insecure_skip_verify,omitempty"` // не проверять сертификат (только для тестов)
+	TLSServerName         string `json:"tls_server_name,omitempty"`          // SNI / имя для проверки сертификата
+
+	AuthType              string `json:"auth_type,omitempty"`          // none|token|token_file|token_exec|oauth2|basic, пусто = по заполненным полям
+	TokenFile             string `json:"token_file,omitempty"`         // файл с токеном, читается на каждый запрос
+	TokenExec             string `json:"token_exec,omitempty"`         // команда, печатающая токен в stdout
+	TokenExecTTLSec       int    `json:"token_exec_ttl_sec,omitempty"` // сколько кэшировать результат token_exec (дефолт 300)
+	OAuth2IssuerURL       string `json:"oauth2_issuer_url,omitempty"`  // issuer для client-credentials
+	OAuth2ClientID        string `json:"oauth2_client_id,omitempty"`
+	OAuth2ClientSecret    string `json:"oauth2_client_secret,omitempty"`
+	OAuth2CredentialsFile string `json:"oauth2_credentials_file,omitempty"` // key-файл Pulsar: client_id/client_secret/issuer_url
+	OAuth2Audience        string `json:"oauth2_audience,omitempty"`
+	OAuth2Scope           string `json:"oauth2_scope,omitempty"`
+	BasicUser             string `json:"basic_user,omitempty"` // basic auth
+	BasicPassword         string `json:"basic_password,omitempty"`
 }